@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/hortelanobruno/foaas-api/audit"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const bearerPrefix = "Bearer "
+
+// ConfigWriter writes the effective configuration, with the secrets redacted.
+type ConfigWriter func(writer io.Writer) error
+
+type runtimeStats struct {
+	GoVersion        string      `json:"go_version"`
+	UptimeInSeconds  float64     `json:"uptime_in_seconds"`
+	Goroutines       int         `json:"goroutines"`
+	GOMAXPROCS       int         `json:"gomaxprocs"`
+	NumCPU           int         `json:"num_cpu"`
+	Memory           memoryStats `json:"memory"`
+	GarbageCollector gcStats     `json:"gc"`
+}
+
+type memoryStats struct {
+	HeapAllocInBytes  uint64 `json:"heap_alloc_in_bytes"`
+	HeapInuseInBytes  uint64 `json:"heap_inuse_in_bytes"`
+	HeapSysInBytes    uint64 `json:"heap_sys_in_bytes"`
+	HeapObjects       uint64 `json:"heap_objects"`
+	TotalAllocInBytes uint64 `json:"total_alloc_in_bytes"`
+	SysInBytes        uint64 `json:"sys_in_bytes"`
+}
+
+type gcStats struct {
+	Count                    uint32     `json:"count"`
+	PauseTotalInMilliseconds float64    `json:"pause_total_in_milliseconds"`
+	Last                     *time.Time `json:"last,omitempty"`
+}
+
+type buildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings,omitempty"`
+}
+
+// NewHandler serves the diagnostics of the process, only to the requests with the token in the
+// Authorization header as a bearer token:
+//   - /debug/pprof/, the profiles of net/http/pprof.
+//   - /debug/runtime, the stats of the memory, the garbage collector and the goroutines.
+//   - /debug/build, the version of Go and the module, and the VCS revision it was built from.
+//   - /debug/config, the effective configuration.
+//
+// The requests are recorded in the audit, including the denied ones.
+func NewHandler(token string, config ConfigWriter, auditor *audit.Auditor) http.Handler {
+	startTime := time.Now()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, readRuntimeStats(startTime))
+	})
+	mux.HandleFunc("/debug/build", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, readBuildInfo())
+	})
+	mux.HandleFunc("/debug/config", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		if err := config(w); err != nil {
+			logrus.Errorf("Error writing the configuration, err: %s", err.Error())
+		}
+	})
+	return authorize(token, auditor, mux)
+}
+
+// authorize compares the hashes of the tokens in constant time, so that neither their content nor their length
+// can be guessed by timing the requests.
+func authorize(token string, auditor *audit.Auditor, next http.Handler) http.Handler {
+	expectedHash := sha256.Sum256([]byte(token))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		givenHash := sha256.Sum256([]byte(strings.TrimPrefix(authorization, bearerPrefix)))
+		details := map[string]string{"remote_addr": r.RemoteAddr, "method": r.Method}
+		if token == "" || !strings.HasPrefix(authorization, bearerPrefix) ||
+			subtle.ConstantTimeCompare(expectedHash[:], givenHash[:]) != 1 {
+			logrus.Warnf("Denying the access to the admin endpoint %s from %s", r.URL.Path, r.RemoteAddr)
+			auditor.Record(audit.Event{Type: audit.EventAdminAccessDenied, Route: r.URL.Path, Details: details})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="foaas-api admin"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		auditor.Record(audit.Event{Type: audit.EventAdminAccessed, Route: r.URL.Path, Details: details})
+		next.ServeHTTP(w, r)
+	})
+}
+
+func readRuntimeStats(startTime time.Time) runtimeStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	stats := runtimeStats{
+		GoVersion:       runtime.Version(),
+		UptimeInSeconds: time.Since(startTime).Seconds(),
+		Goroutines:      runtime.NumGoroutine(),
+		GOMAXPROCS:      runtime.GOMAXPROCS(0),
+		NumCPU:          runtime.NumCPU(),
+		Memory: memoryStats{
+			HeapAllocInBytes:  memStats.HeapAlloc,
+			HeapInuseInBytes:  memStats.HeapInuse,
+			HeapSysInBytes:    memStats.HeapSys,
+			HeapObjects:       memStats.HeapObjects,
+			TotalAllocInBytes: memStats.TotalAlloc,
+			SysInBytes:        memStats.Sys,
+		},
+		GarbageCollector: gcStats{
+			Count:                    memStats.NumGC,
+			PauseTotalInMilliseconds: float64(memStats.PauseTotalNs) / float64(time.Millisecond),
+		},
+	}
+	if memStats.LastGC > 0 {
+		lastGC := time.Unix(0, int64(memStats.LastGC)).UTC()
+		stats.GarbageCollector.Last = &lastGC
+	}
+	return stats
+}
+
+func readBuildInfo() buildInfo {
+	info := buildInfo{GoVersion: runtime.Version()}
+	rawInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Path = rawInfo.Main.Path
+	info.Version = rawInfo.Main.Version
+	info.Settings = make(map[string]string, len(rawInfo.Settings))
+	for _, setting := range rawInfo.Settings {
+		info.Settings[setting.Key] = setting.Value
+	}
+	return info
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logrus.Errorf("Error writing the admin response, err: %s", err.Error())
+	}
+}
@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/audit"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeConfig(writer io.Writer) error {
+	_, err := fmt.Fprint(writer, "admin-token: <redacted>\n")
+	return err
+}
+
+func TestNewHandler(t *testing.T) {
+	cases := []struct {
+		name                string
+		token               string
+		authorization       string
+		path                string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+		expectedAudit       string
+	}{
+		{
+			"Should deny the request without token",
+			"secret",
+			"",
+			"/debug/config",
+			401,
+			"text/plain; charset=utf-8",
+			"Unauthorized",
+			`"type":"admin_access_denied","route":"/debug/config"`,
+		},
+		{
+			"Should deny the request with a wrong token",
+			"secret",
+			"Bearer another secret",
+			"/debug/config",
+			401,
+			"text/plain; charset=utf-8",
+			"Unauthorized",
+			`"type":"admin_access_denied","route":"/debug/config"`,
+		},
+		{
+			"Should deny the request with the token in another scheme",
+			"secret",
+			"Basic secret",
+			"/debug/config",
+			401,
+			"text/plain; charset=utf-8",
+			"Unauthorized",
+			`"type":"admin_access_denied","route":"/debug/config"`,
+		},
+		{
+			"Should deny every request when there's no token",
+			"",
+			"Bearer ",
+			"/debug/config",
+			401,
+			"text/plain; charset=utf-8",
+			"Unauthorized",
+			`"type":"admin_access_denied","route":"/debug/config"`,
+		},
+		{
+			"Should serve the config with the token",
+			"secret",
+			"Bearer secret",
+			"/debug/config",
+			200,
+			"application/yaml",
+			"admin-token: <redacted>",
+			`"type":"admin_accessed","route":"/debug/config"`,
+		},
+		{
+			"Should serve the runtime stats with the token",
+			"secret",
+			"Bearer secret",
+			"/debug/runtime",
+			200,
+			"application/json",
+			fmt.Sprintf(`"go_version":%q`, runtime.Version()),
+			`"type":"admin_accessed","route":"/debug/runtime"`,
+		},
+		{
+			"Should serve the build info with the token",
+			"secret",
+			"Bearer secret",
+			"/debug/build",
+			200,
+			"application/json",
+			fmt.Sprintf(`"go_version":%q`, runtime.Version()),
+			`"type":"admin_accessed","route":"/debug/build"`,
+		},
+		{
+			"Should serve the pprof index with the token",
+			"secret",
+			"Bearer secret",
+			"/debug/pprof/",
+			200,
+			"text/html; charset=utf-8",
+			"heap",
+			`"type":"admin_accessed","route":"/debug/pprof/"`,
+		},
+		{
+			"Should serve the heap profile with the token",
+			"secret",
+			"Bearer secret",
+			"/debug/pprof/heap?debug=1",
+			200,
+			"text/plain; charset=utf-8",
+			"heap profile",
+			`"type":"admin_accessed","route":"/debug/pprof/heap"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			output := &bytes.Buffer{}
+			handler := NewHandler(c.token, writeConfig, audit.NewAuditor(output))
+
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", c.path, nil)
+			request.RemoteAddr = "127.0.0.1:50000"
+			if c.authorization != "" {
+				request.Header.Set("Authorization", c.authorization)
+			}
+
+			// Operation
+			handler.ServeHTTP(w, request)
+
+			// Validation
+			assert.EqualValues(t, c.expectedStatusCode, w.Code)
+			assert.EqualValues(t, c.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), c.expectedBody)
+			assert.Contains(t, output.String(), c.expectedAudit)
+			assert.Contains(t, output.String(), `"remote_addr":"127.0.0.1:50000"`)
+			assert.NotContains(t, output.String(), "secret")
+		})
+	}
+}
+
+func TestNewHandlerShouldServeTheRuntimeStats(t *testing.T) {
+	// Initialization
+	handler := NewHandler("secret", writeConfig, nil)
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/debug/runtime", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+
+	// Operation
+	handler.ServeHTTP(w, request)
+
+	// Validation
+	var stats runtimeStats
+	assert.Nil(t, json.NewDecoder(strings.NewReader(w.Body.String())).Decode(&stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.EqualValues(t, runtime.NumCPU(), stats.NumCPU)
+	assert.Positive(t, stats.Memory.HeapAllocInBytes)
+	assert.GreaterOrEqual(t, stats.UptimeInSeconds, 0.0)
+}
@@ -0,0 +1,131 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindTimeout
+	KindUpstreamClientError
+	KindUpstreamServerError
+	KindDecode
+	KindInvalidResponse
+	KindResponseTooLarge
+	KindValidation
+	KindRateLimited
+	KindCircuitOpen
+	KindNotAcceptable
+	KindNotFound
+	KindCanceled
+)
+
+// StatusClientClosedRequest is the status code of the requests that the clients gave up on, which no
+// client gets to see but that the logs and the metrics record.
+const StatusClientClosedRequest = 499
+
+// kindInfo describes how a kind of error is shown to the clients. An empty detail means the message of
+// the error is safe to show, otherwise the detail replaces it.
+type kindInfo struct {
+	statusCode int
+	code       string
+	detail     string
+}
+
+var kinds = map[Kind]kindInfo{
+	KindInternal:            {http.StatusInternalServerError, "internal_error", "The request couldn't be processed."},
+	KindTimeout:             {http.StatusGatewayTimeout, "upstream_timeout", "foaas didn't respond in time."},
+	KindUpstreamClientError: {http.StatusBadGateway, "upstream_client_error", "foaas rejected the request."},
+	KindUpstreamServerError: {http.StatusBadGateway, "upstream_server_error", "foaas failed to respond."},
+	KindDecode:              {http.StatusBadGateway, "upstream_decode_error", "foaas sent an invalid body."},
+	KindInvalidResponse:     {http.StatusBadGateway, "upstream_invalid_response", "foaas sent an invalid message."},
+	KindResponseTooLarge:    {http.StatusBadGateway, "upstream_response_too_large", "foaas sent a too large body."},
+	KindValidation:          {http.StatusBadRequest, "validation_error", ""},
+	KindRateLimited:         {http.StatusTooManyRequests, "rate_limit_exceeded", ""},
+	KindCircuitOpen:         {http.StatusServiceUnavailable, "circuit_open", "foaas is unavailable for now."},
+	KindNotAcceptable:       {http.StatusNotAcceptable, "not_acceptable", ""},
+	KindNotFound:            {http.StatusNotFound, "not_found", ""},
+	KindCanceled:            {StatusClientClosedRequest, "client_closed_request", "The request was canceled."},
+}
+
+// StatusCode returns the status code of the responses for this kind of error.
+func (k Kind) StatusCode() int {
+	return kinds[k].statusCode
+}
+
+// Code returns the stable code that identifies this kind of error in the responses.
+func (k Kind) Code() string {
+	return kinds[k].code
+}
+
+// Error keeps the kind of an error, which decides the response sent to the clients, along with the
+// error itself, which is only logged.
+type Error struct {
+	Kind Kind
+	err  error
+}
+
+func New(kind Kind, err error) *Error {
+	return &Error{
+		Kind: kind,
+		err:  err,
+	}
+}
+
+// NewUpstreamStatus returns the error for a response of foaas with an unexpected status code.
+func NewUpstreamStatus(statusCode int, err error) *Error {
+	if statusCode >= http.StatusInternalServerError {
+		return New(KindUpstreamServerError, err)
+	}
+	return New(KindUpstreamClientError, err)
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Detail returns the description of the error that is safe to show to the clients.
+func (e *Error) Detail() string {
+	if detail := kinds[e.Kind].detail; detail != "" {
+		return detail
+	}
+	return e.err.Error()
+}
+
+// KindOf returns the kind of the error, the errors without one are internal unless they are timeouts or
+// cancellations.
+func KindOf(err error) Kind {
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return apiError.Kind
+	}
+	if IsTimeout(err) {
+		return KindTimeout
+	}
+	if IsCanceled(err) {
+		return KindCanceled
+	}
+	return KindInternal
+}
+
+// IsCanceled reports whether the error comes from a canceled context, like the one of a request whose client
+// disconnected, which is neither an error of the server nor of foaas.
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// IsTimeout reports whether the error comes from an exceeded deadline, either of a context or of a
+// network operation.
+func IsTimeout(err error) bool {
+	var netError net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout())
+}
@@ -0,0 +1,97 @@
+package apierror
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		expectedKind Kind
+	}{
+		{
+			"Should return the kind of the error",
+			New(KindValidation, fmt.Errorf("userID can't be empty")),
+			KindValidation,
+		},
+		{
+			"Should return the kind of a wrapped error",
+			fmt.Errorf("error getting the message, err: %w", New(KindDecode, fmt.Errorf("unexpected EOF"))),
+			KindDecode,
+		},
+		{
+			"Should return upstream client error when foaas responds with a client error",
+			NewUpstreamStatus(http.StatusNotFound, fmt.Errorf("error executing request, status code: 404")),
+			KindUpstreamClientError,
+		},
+		{
+			"Should return upstream server error when foaas responds with a server error",
+			NewUpstreamStatus(http.StatusBadGateway, fmt.Errorf("error executing request, status code: 502")),
+			KindUpstreamServerError,
+		},
+		{
+			"Should return timeout when a context deadline is exceeded",
+			fmt.Errorf("error getting the message, err: %w", context.DeadlineExceeded),
+			KindTimeout,
+		},
+		{
+			"Should return canceled when a context is canceled",
+			fmt.Errorf("error getting the message, err: %w", context.Canceled),
+			KindCanceled,
+		},
+		{
+			"Should return internal when the error has no kind",
+			fmt.Errorf("an error"),
+			KindInternal,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			kind := KindOf(c.err)
+
+			// Validation
+			assert.EqualValues(t, c.expectedKind, kind)
+		})
+	}
+}
+
+func TestErrorDetail(t *testing.T) {
+	cases := []struct {
+		name           string
+		err            *Error
+		expectedDetail string
+	}{
+		{
+			"Should return the message of validation errors",
+			New(KindValidation, fmt.Errorf("userID can't be empty")),
+			"userID can't be empty",
+		},
+		{
+			"Should hide the message of internal errors",
+			New(KindInternal, fmt.Errorf("error building the request")),
+			"The request couldn't be processed.",
+		},
+		{
+			"Should hide the message of upstream errors",
+			NewUpstreamStatus(http.StatusInternalServerError, fmt.Errorf("error executing request, status code: 500")),
+			"foaas failed to respond.",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			detail := c.err.Detail()
+
+			// Validation
+			assert.EqualValues(t, c.expectedDetail, detail)
+		})
+	}
+}
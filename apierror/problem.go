@@ -0,0 +1,50 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of the error responses, as described by RFC 7807, extended with the stable code
+// of the error.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+// NewProblem describes the error for the clients, never exposing the message of internal errors.
+func NewProblem(err error, instance string) Problem {
+	var apiError *Error
+	if !errors.As(err, &apiError) {
+		apiError = New(KindOf(err), err)
+	}
+	statusCode := apiError.Kind.StatusCode()
+	title := http.StatusText(statusCode)
+	if statusCode == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	return Problem{
+		Type:     "about:blank",
+		Title:    title,
+		Status:   statusCode,
+		Detail:   apiError.Detail(),
+		Instance: instance,
+		Code:     apiError.Kind.Code(),
+	}
+}
+
+// WriteProblem aborts the request with the problem of the error.
+func WriteProblem(ginContext *gin.Context, err error) {
+	problem := NewProblem(err, ginContext.Request.URL.Path)
+	body, _ := json.Marshal(problem)
+	ginContext.Data(problem.Status, ProblemContentType, body)
+	ginContext.Abort()
+}
@@ -0,0 +1,94 @@
+package apierror
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	cases := []struct {
+		name            string
+		err             error
+		expectedProblem Problem
+	}{
+		{
+			"Should describe the error with its kind",
+			New(KindRateLimited, fmt.Errorf("too many requests, try again later")),
+			Problem{
+				Type:     "about:blank",
+				Title:    "Too Many Requests",
+				Status:   http.StatusTooManyRequests,
+				Detail:   "too many requests, try again later",
+				Instance: "/message",
+				Code:     "rate_limit_exceeded",
+			},
+		},
+		{
+			"Should describe a wrapped error with its kind",
+			fmt.Errorf("error getting the message, err: %w", New(KindTimeout, fmt.Errorf("deadline exceeded"))),
+			Problem{
+				Type:     "about:blank",
+				Title:    "Gateway Timeout",
+				Status:   http.StatusGatewayTimeout,
+				Detail:   "foaas didn't respond in time.",
+				Instance: "/message",
+				Code:     "upstream_timeout",
+			},
+		},
+		{
+			"Should describe a canceled request as closed by the client",
+			New(KindCanceled, fmt.Errorf("error doing the request, err: context canceled")),
+			Problem{
+				Type:     "about:blank",
+				Title:    "Client Closed Request",
+				Status:   StatusClientClosedRequest,
+				Detail:   "The request was canceled.",
+				Instance: "/message",
+				Code:     "client_closed_request",
+			},
+		},
+		{
+			"Should describe an error without kind as internal",
+			fmt.Errorf("an error"),
+			Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "The request couldn't be processed.",
+				Instance: "/message",
+				Code:     "internal_error",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			problem := NewProblem(c.err, "/message")
+
+			// Validation
+			assert.EqualValues(t, c.expectedProblem, problem)
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	// Initialization
+	w := httptest.NewRecorder()
+	context, _ := gin.CreateTestContext(w)
+	context.Request, _ = http.NewRequest("GET", "/message", nil)
+
+	// Operation
+	WriteProblem(context, New(KindValidation, fmt.Errorf("userID can't be empty")))
+
+	// Validation
+	assert.True(t, context.IsAborted())
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.EqualValues(t, `{"type":"about:blank","title":"Bad Request","status":400,`+
+		`"detail":"userID can't be empty","instance":"/message","code":"validation_error"}`, w.Body.String())
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	EventRateLimitDenied    = "rate_limit_denied"
+	EventQuotaExhausted     = "quota_exhausted"
+	EventConfigReloaded     = "config_reloaded"
+	EventConfigReloadFailed = "config_reload_failed"
+	EventAdminAccessed      = "admin_accessed"
+	EventAdminAccessDenied  = "admin_access_denied"
+)
+
+// Event is a line of the audit log. The user IDs are recorded as they are, the audit log is kept apart from
+// the logs, which are redacted.
+type Event struct {
+	Time      time.Time         `json:"time"`
+	Type      string            `json:"type"`
+	RequestID string            `json:"request_id,omitempty"`
+	UserID    string            `json:"user_id,omitempty"`
+	Route     string            `json:"route,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Auditor records the events as JSON lines. A nil auditor records nothing, which is the case when the audit
+// is disabled.
+type Auditor struct {
+	writer io.Writer
+	mutex  *sync.Mutex
+	now    func() time.Time
+}
+
+func NewAuditor(writer io.Writer) *Auditor {
+	return &Auditor{
+		writer: writer,
+		mutex:  &sync.Mutex{},
+		now:    time.Now,
+	}
+}
+
+// Record writes the event at the current time. The errors are logged, the audit never fails the requests.
+func (a *Auditor) Record(event Event) {
+	if a == nil {
+		return
+	}
+
+	event.Time = a.now().UTC()
+	line, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("Error encoding the audit event %s, err: %s", event.Type, err.Error())
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, err := a.writer.Write(append(line, '\n')); err != nil {
+		logrus.Errorf("Error writing the audit event %s, err: %s", event.Type, err.Error())
+	}
+}
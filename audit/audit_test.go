@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	cases := []struct {
+		name         string
+		event        Event
+		expectedLine string
+	}{
+		{
+			"Should record a rate limit denial",
+			Event{Type: EventRateLimitDenied, RequestID: "abc", UserID: "123", Route: "/message"},
+			`{"time":"2022-03-30T00:00:00Z","type":"rate_limit_denied","request_id":"abc","user_id":"123",` +
+				`"route":"/message"}` + "\n",
+		},
+		{
+			"Should record the details of the event",
+			Event{Type: EventConfigReloaded, Details: map[string]string{"component": "tls_certificate"}},
+			`{"time":"2022-03-30T00:00:00Z","type":"config_reloaded","details":{"component":"tls_certificate"}}` +
+				"\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			output := &bytes.Buffer{}
+			auditor := NewAuditor(output)
+			auditor.now = func() time.Time {
+				return time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+			}
+
+			// Operation
+			auditor.Record(c.event)
+
+			// Validation
+			assert.EqualValues(t, c.expectedLine, output.String())
+		})
+	}
+}
+
+func TestRecordShouldNotFailWhenTheAuditIsDisabled(t *testing.T) {
+	// Initialization
+	var auditor *Auditor
+
+	// Operation
+	auditor.Record(Event{Type: EventRateLimitDenied})
+}
+
+type failingWriter struct{}
+
+func (f failingWriter) Write(_ []byte) (int, error) {
+	return 0, fmt.Errorf("disk is full")
+}
+
+func TestRecordShouldNotFailWhenTheEventCantBeWritten(t *testing.T) {
+	// Initialization
+	auditor := NewAuditor(failingWriter{})
+
+	// Operation
+	auditor.Record(Event{Type: EventRateLimitDenied})
+}
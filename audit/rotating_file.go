@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000000000"
+
+// RotatingFile appends to a file that is rotated when it would exceed the maximum size, keeping the rotated
+// files, named after the time of the rotation like audit-20220330T000000.000000000.jsonl for audit.jsonl, until
+// they are older than the maximum age.
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	file    *os.File
+	size    int64
+	mutex   *sync.Mutex
+	now     func() time.Time
+}
+
+// OpenRotatingFile opens the file to append to it, there is no maximum size or age when they are 0.
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration) (*RotatingFile, error) {
+	rotatingFile := &RotatingFile{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		mutex:   &sync.Mutex{},
+		now:     time.Now,
+	}
+	if err := rotatingFile.open(); err != nil {
+		return nil, err
+	}
+	if err := rotatingFile.removeExpiredBackups(); err != nil {
+		return nil, err
+	}
+	return rotatingFile, nil
+}
+
+// Write appends the bytes, which are never split between two files. The file is reopened when a previous
+// rotation couldn't reopen it.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening the file %s, err: %s", f.path, err.Error())
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error reading the size of the file %s, err: %s", f.path, err.Error())
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate moves the file to a backup and opens a new one. When the file can't be moved, it keeps appending to
+// it rather than losing the events, so it only fails when the file can't be opened again.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		logrus.Errorf("Error closing the file %s to rotate it, err: %s", f.path, err.Error())
+	}
+	f.file = nil
+	prefix, extension := f.backupNameParts()
+	backup := prefix + f.now().UTC().Format(backupTimeFormat) + extension
+	renameErr := os.Rename(f.path, backup)
+	if renameErr != nil {
+		logrus.Errorf("Error rotating the file %s, err: %s", f.path, renameErr.Error())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	if renameErr == nil {
+		if err := f.removeExpiredBackups(); err != nil {
+			logrus.Errorf("Error removing the expired backups of %s, err: %s", f.path, err.Error())
+		}
+	}
+	return nil
+}
+
+// removeExpiredBackups removes the rotated files older than the maximum age, by the time of their rotation.
+func (f *RotatingFile) removeExpiredBackups() error {
+	if f.maxAge <= 0 {
+		return nil
+	}
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+	prefix, extension := f.backupNameParts()
+	for _, backup := range backups {
+		rotatedAt, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(backup, prefix),
+			extension))
+		if err != nil || f.now().Sub(rotatedAt) <= f.maxAge {
+			continue
+		}
+		if err := os.Remove(backup); err != nil {
+			return fmt.Errorf("error removing the file %s, err: %s", backup, err.Error())
+		}
+	}
+	return nil
+}
+
+// backups returns the rotated files, from the oldest to the newest.
+func (f *RotatingFile) backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, fmt.Errorf("error listing the rotated files of %s, err: %s", f.path, err.Error())
+	}
+	prefix, extension := f.backupNameParts()
+	var backups []string
+	for _, entry := range entries {
+		backup := filepath.Join(filepath.Dir(f.path), entry.Name())
+		if !entry.IsDir() && strings.HasPrefix(backup, prefix) && strings.HasSuffix(backup, extension) {
+			backups = append(backups, backup)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func (f *RotatingFile) backupNameParts() (string, string) {
+	extension := filepath.Ext(f.path)
+	return strings.TrimSuffix(filepath.Clean(f.path), extension) + "-", extension
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func listFiles(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		content, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		assert.Nil(t, err)
+		files[entry.Name()] = string(content)
+	}
+	return files
+}
+
+func TestRotatingFileShouldAppendToTheExistingFile(t *testing.T) {
+	// Initialization
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("line 1\n"), 0600))
+	file, err := OpenRotatingFile(path, 0, 0)
+	assert.Nil(t, err)
+
+	// Operation
+	_, err = file.Write([]byte("line 2\n"))
+
+	// Validation
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	assert.EqualValues(t, map[string]string{"audit.jsonl": "line 1\nline 2\n"}, listFiles(t, filepath.Dir(path)))
+}
+
+func TestRotatingFileShouldRotateWhenTheFileWouldExceedTheMaximumSize(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	file, err := OpenRotatingFile(filepath.Join(dir, "audit.jsonl"), 14, 0)
+	assert.Nil(t, err)
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	file.now = func() time.Time {
+		return now
+	}
+
+	// Operation
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "a longer line 4\n", "line 5\n"} {
+		_, err := file.Write([]byte(line))
+		assert.Nil(t, err)
+		now = now.Add(time.Second)
+	}
+
+	// Validation
+	assert.Nil(t, file.Close())
+	assert.EqualValues(t, map[string]string{
+		"audit-20220330T000002.000000000.jsonl": "line 1\nline 2\n",
+		"audit-20220330T000003.000000000.jsonl": "line 3\n",
+		"audit-20220330T000004.000000000.jsonl": "a longer line 4\n",
+		"audit.jsonl":                           "line 5\n",
+	}, listFiles(t, dir))
+}
+
+func TestRotatingFileShouldRemoveTheRotatedFilesOlderThanTheMaximumAge(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	expired := "audit-" + time.Now().UTC().Add(-30*24*time.Hour).Format(backupTimeFormat) + ".jsonl"
+	recent := "audit-" + time.Now().UTC().Add(-24*time.Hour).Format(backupTimeFormat) + ".jsonl"
+	for _, name := range []string{expired, recent, "audit-backup.jsonl", "other-20220301T000000.000000000.jsonl"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("line\n"), 0600))
+	}
+
+	// Operation
+	file, err := OpenRotatingFile(filepath.Join(dir, "audit.jsonl"), 0, 7*24*time.Hour)
+
+	// Validation
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	var names []string
+	for name := range listFiles(t, dir) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.EqualValues(t, []string{recent, "audit-backup.jsonl", "audit.jsonl",
+		"other-20220301T000000.000000000.jsonl"}, names)
+}
+
+func TestRotatingFileShouldRemoveTheExpiredRotatedFilesWhenRotating(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	file, err := OpenRotatingFile(filepath.Join(dir, "audit.jsonl"), 5, 7*24*time.Hour)
+	assert.Nil(t, err)
+	now := time.Date(2022, time.March, 1, 0, 0, 0, 00, time.UTC)
+	file.now = func() time.Time {
+		return now
+	}
+
+	// Operation
+	for _, days := range []int{0, 1, 30} {
+		now = time.Date(2022, time.March, 1+days, 0, 0, 0, 00, time.UTC)
+		_, err := file.Write([]byte("line\n"))
+		assert.Nil(t, err)
+	}
+
+	// Validation
+	assert.Nil(t, file.Close())
+	var names []string
+	for name := range listFiles(t, dir) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.EqualValues(t, []string{"audit-20220331T000000.000000000.jsonl", "audit.jsonl"}, names)
+}
+
+func TestRotatingFileShouldKeepAppendingWhenTheFileCantBeRotated(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	file, err := OpenRotatingFile(filepath.Join(dir, "audit.jsonl"), 7, 0)
+	assert.Nil(t, err)
+	file.now = func() time.Time {
+		return time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	}
+	// A directory that isn't empty can't be replaced by the backup.
+	backup := filepath.Join(dir, "audit-20220330T000000.000000000.jsonl")
+	assert.Nil(t, os.MkdirAll(filepath.Join(backup, "taken"), 0700))
+
+	// Operation
+	_, errWrite1 := file.Write([]byte("line 1\n"))
+	_, errWrite2 := file.Write([]byte("line 2\n"))
+	_, errWrite3 := file.Write([]byte("line 3\n"))
+
+	// Validation
+	assert.Nil(t, errWrite1)
+	assert.Nil(t, errWrite2)
+	assert.Nil(t, errWrite3)
+	assert.Nil(t, file.Close())
+	content, err := ioutil.ReadFile(filepath.Join(dir, "audit.jsonl"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "line 1\nline 2\nline 3\n", string(content))
+}
+
+func TestRotatingFileShouldReopenTheFileWhenTheRotationCouldntReopenIt(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	file, err := OpenRotatingFile(path, 7, 0)
+	assert.Nil(t, err)
+	file.now = func() time.Time {
+		return time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	}
+	_, err = file.Write([]byte("line 1\n"))
+	assert.Nil(t, err)
+	// The file is replaced by a directory that can neither be rotated nor opened.
+	assert.Nil(t, os.Remove(path))
+	assert.Nil(t, os.MkdirAll(filepath.Join(path, "taken"), 0700))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "audit-20220330T000000.000000000.jsonl", "taken"), 0700))
+
+	// Operation
+	_, errFailedWrite := file.Write([]byte("line 2\n"))
+	assert.Nil(t, os.RemoveAll(path))
+	_, errWrite := file.Write([]byte("line 3\n"))
+
+	// Validation
+	assert.NotNil(t, errFailedWrite)
+	assert.Nil(t, errWrite)
+	assert.Nil(t, file.Close())
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "line 3\n", string(content))
+}
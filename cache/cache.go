@@ -0,0 +1,14 @@
+package cache
+
+type Cache interface {
+	Get(key string) (value interface{}, fresh bool, found bool)
+	Set(key string, value interface{})
+	Stats() Stats
+}
+
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+type LocalCache struct {
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   Stats
+	mutex   *sync.Mutex
+	now     func() time.Time
+}
+
+func NewLocalCache(size int, ttl time.Duration) *LocalCache {
+	return &LocalCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element, size),
+		lru:     list.New(),
+		mutex:   &sync.Mutex{},
+		now:     time.Now,
+	}
+}
+
+// Get returns the value stored for the key and whether it's still inside its TTL.
+// Expired values are kept until they are evicted, so callers can fall back to them when the source fails.
+func (c *LocalCache) Get(key string) (interface{}, bool, bool) {
+	now := c.now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		c.stats.Misses++
+		return nil, false, false
+	}
+
+	c.lru.MoveToFront(element)
+	e := element.Value.(*entry)
+	if now.After(e.expiresAt) {
+		c.stats.Misses++
+		return e.value, false, true
+	}
+
+	c.stats.Hits++
+	return e.value, true, true
+}
+
+// Set stores the value for the key, evicting the least recently used values when the cache is full.
+func (c *LocalCache) Set(key string, value interface{}) {
+	now := c.now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.lru.MoveToFront(element)
+		e := element.Value.(*entry)
+		e.value = value
+		e.expiresAt = now.Add(c.ttl)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{
+		key:       key,
+		value:     value,
+		expiresAt: now.Add(c.ttl),
+	})
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *LocalCache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetShouldReturnNotFoundWhenKeyIsMissing(t *testing.T) {
+	// Initialization
+	cache := NewLocalCache(2, time.Minute)
+
+	// Operation
+	value, fresh, found := cache.Get("asshole/123")
+
+	// Validation
+	assert.Nil(t, value)
+	assert.False(t, fresh)
+	assert.False(t, found)
+	assert.EqualValues(t, Stats{Misses: 1}, cache.Stats())
+}
+
+func TestGetShouldReturnFreshValueWhenTTLHasNotExpired(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	cache := NewLocalCache(2, time.Minute)
+	cache.now = func() time.Time {
+		return now
+	}
+	cache.Set("asshole/123", "value")
+	now = now.Add(time.Minute)
+
+	// Operation
+	value, fresh, found := cache.Get("asshole/123")
+
+	// Validation
+	assert.EqualValues(t, "value", value)
+	assert.True(t, fresh)
+	assert.True(t, found)
+	assert.EqualValues(t, Stats{Hits: 1, Size: 1}, cache.Stats())
+}
+
+func TestGetShouldReturnStaleValueWhenTTLHasExpired(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	cache := NewLocalCache(2, time.Minute)
+	cache.now = func() time.Time {
+		return now
+	}
+	cache.Set("asshole/123", "value")
+	now = now.Add(time.Minute + time.Millisecond)
+
+	// Operation
+	value, fresh, found := cache.Get("asshole/123")
+
+	// Validation
+	assert.EqualValues(t, "value", value)
+	assert.False(t, fresh)
+	assert.True(t, found)
+	assert.EqualValues(t, Stats{Misses: 1, Size: 1}, cache.Stats())
+}
+
+func TestSetShouldRefreshTheTTLWhenKeyExists(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	cache := NewLocalCache(2, time.Minute)
+	cache.now = func() time.Time {
+		return now
+	}
+	cache.Set("asshole/123", "old value")
+	now = now.Add(2 * time.Minute)
+
+	// Operation
+	cache.Set("asshole/123", "new value")
+
+	// Validation
+	value, fresh, found := cache.Get("asshole/123")
+	assert.EqualValues(t, "new value", value)
+	assert.True(t, fresh)
+	assert.True(t, found)
+	assert.EqualValues(t, Stats{Hits: 1, Size: 1}, cache.Stats())
+}
+
+func TestSetShouldEvictTheLeastRecentlyUsedValueWhenCacheIsFull(t *testing.T) {
+	// Initialization
+	cache := NewLocalCache(2, time.Minute)
+	cache.Set("asshole/1", "1")
+	cache.Set("asshole/2", "2")
+	cache.Get("asshole/1")
+
+	// Operation
+	cache.Set("asshole/3", "3")
+
+	// Validation
+	_, _, found1 := cache.Get("asshole/1")
+	_, _, found2 := cache.Get("asshole/2")
+	_, _, found3 := cache.Get("asshole/3")
+	assert.True(t, found1)
+	assert.False(t, found2)
+	assert.True(t, found3)
+	assert.EqualValues(t, Stats{Hits: 3, Misses: 1, Evictions: 1, Size: 2}, cache.Stats())
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/hortelanobruno/foaas-api/cmd/config"
 	"github.com/hortelanobruno/foaas-api/cmd/server"
 	"github.com/spf13/cobra"
 )
@@ -8,5 +9,6 @@ import (
 func Cmds() *cobra.Command {
 	rootCmd := &cobra.Command{}
 	rootCmd.AddCommand(server.NewRunnable().Cmd())
+	rootCmd.AddCommand(config.NewRunnable().Cmd())
 	return rootCmd
 }
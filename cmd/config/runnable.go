@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/cmd/server"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type Runnable struct{}
+
+func NewRunnable() *Runnable {
+	return &Runnable{}
+}
+
+func (r *Runnable) Cmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspects the configuration of foaas API",
+		Long: `Inspects the configuration of foaas API, merged from the defaults, the config file, the ` +
+			`FOAAS_API_* environment variables and the flags, in that order of precedence`,
+	}
+	cmd.AddCommand(r.printCmd(), r.validateCmd())
+	return cmd
+}
+
+func (r *Runnable) printCmd() *cobra.Command {
+	options := &server.Options{}
+	var cmd = &cobra.Command{
+		Use:   "print",
+		Short: "Prints the effective configuration, with the secrets redacted",
+		Long:  `Prints the effective configuration as YAML, with the secrets redacted`,
+	}
+	options.AddFlags(cmd.Flags())
+	server.AddConfigFlag(cmd.Flags())
+
+	cmd.Run = func(cmd *cobra.Command, _ []string) {
+		if err := server.LoadConfig(cmd.Flags()); err != nil {
+			logrus.Fatalf("Error loading the configuration, err: %s", err.Error())
+		}
+		if err := server.PrintConfig(cmd.OutOrStdout(), cmd.Flags()); err != nil {
+			logrus.Fatalf("Error printing the configuration, err: %s", err.Error())
+		}
+	}
+	return cmd
+}
+
+func (r *Runnable) validateCmd() *cobra.Command {
+	options := &server.Options{}
+	var cmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validates the configuration",
+		Long:  `Validates the configuration, exiting with an error when it's not valid`,
+	}
+	options.AddFlags(cmd.Flags())
+	server.AddConfigFlag(cmd.Flags())
+
+	cmd.Run = func(cmd *cobra.Command, _ []string) {
+		if err := server.LoadConfig(cmd.Flags()); err != nil {
+			logrus.Fatalf("Error loading the configuration, err: %s", err.Error())
+		}
+		if err := options.Validate(); err != nil {
+			logrus.Fatalf("Error validating the configuration, err: %s", err.Error())
+		}
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "The configuration is valid")
+	}
+	return cmd
+}
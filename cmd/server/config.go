@@ -0,0 +1,277 @@
+package server
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/hortelanobruno/foaas-api/domain/validator"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"github.com/hortelanobruno/foaas-api/tracing"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	configFlag       = "config"
+	envPrefix        = "FOAAS_API_"
+	secretAnnotation = "secret"
+	redactedValue    = "<redacted>"
+)
+
+// AddConfigFlag adds the flag of the config file, see LoadConfig.
+func AddConfigFlag(flags *pflag.FlagSet) {
+	flags.String(configFlag, "", "YAML or TOML file with the configuration, the keys are the names of the flags")
+}
+
+// markSecret redacts the value of the flag when the configuration is printed.
+func markSecret(flags *pflag.FlagSet, name string) {
+	_ = flags.SetAnnotation(name, secretAnnotation, []string{"true"})
+}
+
+// LoadConfig fills the flags that weren't set in the command line. Each one takes, in order, its
+// environment variable, like FOAAS_API_RATE_LIMIT_COUNT for --rate-limit-count, its key in the config file
+// or its default. The config file is the one of --config or FOAAS_API_CONFIG.
+func LoadConfig(flags *pflag.FlagSet) error {
+	configFile, _ := flags.GetString(configFlag)
+	if !flags.Changed(configFlag) {
+		configFile = os.Getenv(envName(configFlag))
+	}
+	fileValues, err := readConfigFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	var unknownKeys []string
+	for key := range fileValues {
+		if flag := flags.Lookup(key); flag == nil || key == configFlag {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		return fmt.Errorf("config file %s has unknown keys: %s", configFile, strings.Join(unknownKeys, ", "))
+	}
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == configFlag {
+			return
+		}
+		source := envName(flag.Name)
+		value, exists := os.LookupEnv(source)
+		if !exists {
+			source = configFile
+			value, exists = fileValues[flag.Name]
+		}
+		if !exists {
+			return
+		}
+		if setErr := flag.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s in %s, err: %s", value, flag.Name, source, setErr.Error())
+		}
+	})
+	return err
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// readConfigFile returns the values of the config file as strings, which the flags know how to parse. The
+// lists are joined by commas.
+func readConfigFile(file string) (map[string]string, error) {
+	if file == "" {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the config file, err: %s", err.Error())
+	}
+
+	rawValues := map[string]interface{}{}
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &rawValues)
+	case ".toml":
+		err = toml.Unmarshal(content, &rawValues)
+	default:
+		return nil, fmt.Errorf("config file %s must be a .yaml, .yml or .toml file", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the config file, err: %s", err.Error())
+	}
+
+	values := make(map[string]string, len(rawValues))
+	for key, rawValue := range rawValues {
+		value, err := configValueString(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s in %s, err: %s", key, file, err.Error())
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func configValueString(rawValue interface{}) (string, error) {
+	switch value := rawValue.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, rawItem := range value {
+			item, err := configValueString(rawItem)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("it must be a value or a list")
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+// PrintConfig writes the effective configuration as YAML, with the secrets redacted.
+func PrintConfig(writer io.Writer, flags *pflag.FlagSet) error {
+	config := &yaml.Node{Kind: yaml.MappingNode}
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Name == configFlag {
+			return
+		}
+		var value interface{} = printableValue(flags, flag)
+		if _, secret := flag.Annotations[secretAnnotation]; secret && flag.Value.String() != "" {
+			value = redactedValue
+		}
+		valueNode := &yaml.Node{}
+		if err = valueNode.Encode(value); err == nil {
+			config.Content = append(config.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: flag.Name}, valueNode)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// printableValue returns the value of the flag with its type, to print the numbers and lists as such.
+func printableValue(flags *pflag.FlagSet, flag *pflag.Flag) interface{} {
+	var value interface{}
+	var err error
+	switch flag.Value.Type() {
+	case "bool":
+		value, err = flags.GetBool(flag.Name)
+	case "int":
+		value, err = flags.GetInt(flag.Name)
+	case "int64":
+		value, err = flags.GetInt64(flag.Name)
+	case "float64":
+		value, err = flags.GetFloat64(flag.Name)
+	case "intSlice":
+		value, err = flags.GetIntSlice(flag.Name)
+	case "stringSlice":
+		value, err = flags.GetStringSlice(flag.Name)
+	default:
+		return flag.Value.String()
+	}
+	if err != nil {
+		return flag.Value.String()
+	}
+	return value
+}
+
+// Validate checks the options that don't need to read any file or to reach foaas.
+func (o *Options) Validate() error {
+	if _, err := logrus.ParseLevel(o.LogLevel); err != nil {
+		return fmt.Errorf("log level %q is not valid", o.LogLevel)
+	}
+	if _, err := logging.NewFormatter(o.LogFormat); err != nil {
+		return err
+	}
+	if _, err := logging.NewRedactor(o.LogUserIDRedaction, o.LogRedactionKey, o.LogScrubFields); err != nil {
+		return err
+	}
+	if o.RateLimitEnable && (o.RateLimitCount <= 0 || o.RateLimitWindowInMilliseconds <= 0) {
+		return fmt.Errorf("rate limit count and window must be positive")
+	}
+	if o.RetryMaxAttempts < 1 {
+		return fmt.Errorf("retry max attempts must be at least 1")
+	}
+	if o.RetryBaseBackoffInMilliseconds <= 0 || o.RetryMaxBackoffInMilliseconds <= 0 {
+		return fmt.Errorf("retry base and max backoffs must be positive")
+	}
+	if o.RetryMaxBackoffInMilliseconds < o.RetryBaseBackoffInMilliseconds {
+		return fmt.Errorf("retry max backoff can't be less than the base backoff")
+	}
+	if o.HedgingEnable && (o.HedgingPercentile <= 0 || o.HedgingPercentile > 100) {
+		return fmt.Errorf("hedging percentile must be between 0 and 100")
+	}
+	if o.CircuitBreakerEnable && (o.CircuitBreakerFailureThreshold <= 0 ||
+		o.CircuitBreakerCoolDownInMilliseconds <= 0 || o.CircuitBreakerHalfOpenMaxRequests <= 0) {
+		return fmt.Errorf("circuit breaker failure threshold, cool down and half open max requests must be positive")
+	}
+	if o.CacheEnable && (o.CacheSize <= 0 || o.CacheTTLInMilliseconds <= 0) {
+		return fmt.Errorf("cache size and ttl must be positive")
+	}
+	if o.TimeoutInMilliseconds <= 0 || o.UpstreamIdleConnectionTimeoutInMilliseconds <= 0 ||
+		o.UpstreamDialTimeoutInMilliseconds <= 0 || o.UpstreamTLSHandshakeTimeoutInMilliseconds <= 0 ||
+		o.ShutdownTimeoutInMilliseconds <= 0 || o.ReadinessProbeTimeoutInMilliseconds <= 0 ||
+		o.ServerReadHeaderTimeoutInMilliseconds <= 0 || o.ServerReadTimeoutInMilliseconds <= 0 ||
+		o.ServerIdleTimeoutInMilliseconds <= 0 {
+		return fmt.Errorf("timeouts must be positive")
+	}
+	if o.UpstreamResponseHeaderTimeoutInMilliseconds < 0 {
+		return fmt.Errorf("upstream response header timeout can't be negative")
+	}
+	if o.UpstreamMaxResponseSizeInBytes <= 0 {
+		return fmt.Errorf("upstream max response size must be positive")
+	}
+	if o.UserIDMaxLength < 0 {
+		return fmt.Errorf("user ID max length can't be negative")
+	}
+	if baseURL, err := url.Parse(o.UpstreamBaseURL); err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return fmt.Errorf("upstream base url %q is not valid", o.UpstreamBaseURL)
+	}
+	if _, err := validator.NewCharsetRule(o.UserIDCharset); err != nil {
+		return err
+	}
+	if (o.TLSCert == "") != (o.TLSKey == "") {
+		return fmt.Errorf("both the TLS certificate and key are needed")
+	}
+	if _, exists := clientAuthTypes[o.TLSClientAuth]; !exists {
+		return fmt.Errorf("TLS client auth %q is not supported", o.TLSClientAuth)
+	}
+	if o.AuditLogMaxSizeInBytes < 0 || o.AuditLogMaxAgeInDays < 0 {
+		return fmt.Errorf("audit log max size and age can't be negative")
+	}
+	if o.AdminListen != "" && o.AdminToken == "" {
+		return fmt.Errorf("admin token is needed to enable the admin listener")
+	}
+	return o.tracingConfig().Validate()
+}
+
+func (o *Options) tracingConfig() tracing.Config {
+	return tracing.Config{
+		Exporter:     o.TracingExporter,
+		OTLPEndpoint: o.TracingOTLPEndpoint,
+		OTLPInsecure: o.TracingOTLPInsecure,
+		SampleRatio:  o.TracingSampleRatio,
+	}
+}
@@ -1,10 +1,79 @@
 package server
 
+import (
+	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/logging"
+)
+
 const (
-	defaultPort                          = 4000
-	defaultLogLevel                      = "debug"
-	defaultRateLimitEnable               = true
-	defaultRateLimitCount                = 5
-	defaultRateLimitWindowInMilliseconds = 10000
-	defaultTimeoutInMilliseconds         = 10000
+	defaultListen                                      = ":4000"
+	defaultLogLevel                                    = "info"
+	defaultLogFormat                                   = "json"
+	defaultLogPII                                      = false
+	defaultLogUserIDRedaction                          = logging.UserIDRedactionHash
+	defaultLogRedactionKey                             = ""
+	defaultRateLimitEnable                             = true
+	defaultRateLimitCount                              = 5
+	defaultRateLimitWindowInMilliseconds               = 10000
+	defaultTimeoutInMilliseconds                       = 10000
+	defaultRetryMaxAttempts                            = 3
+	defaultRetryBaseBackoffInMilliseconds              = 100
+	defaultRetryMaxBackoffInMilliseconds               = 2000
+	defaultHedgingEnable                               = false
+	defaultHedgingPercentile                           = 95.0
+	defaultHedgingDelayInMilliseconds                  = 200
+	defaultHedgingBudgetPercent                        = 10.0
+	defaultCircuitBreakerEnable                        = true
+	defaultCircuitBreakerFailureThreshold              = 5
+	defaultCircuitBreakerCoolDownInMilliseconds        = 30000
+	defaultCircuitBreakerHalfOpenMaxRequests           = 1
+	defaultCoalescingEnable                            = true
+	defaultCacheEnable                                 = true
+	defaultCacheSize                                   = 1000
+	defaultCacheTTLInMilliseconds                      = 60000
+	defaultMaxMessageLength                            = 1000
+	defaultMaxSubtitleLength                           = 200
+	defaultUserIDMaxLength                             = 64
+	defaultUserIDCharset                               = `\p{L}\p{N} ._@-`
+	defaultUpstreamBaseURL                             = constants.FoaasBaseURL
+	defaultUpstreamTLSMinVersion                       = "1.2"
+	defaultUpstreamMaxIdleConnections                  = 100
+	defaultUpstreamMaxIdleConnectionsPerHost           = 10
+	defaultUpstreamIdleConnectionTimeoutInMilliseconds = 90000
+	defaultUpstreamDialTimeoutInMilliseconds           = 30000
+	defaultUpstreamKeepAliveInMilliseconds             = 30000
+	defaultUpstreamTLSHandshakeTimeoutInMilliseconds   = 10000
+	defaultUpstreamResponseHeaderTimeoutInMilliseconds = 0
+	defaultUpstreamHTTP2Enable                         = true
+	defaultUpstreamKeepAliveEnable                     = true
+	defaultUpstreamMaxResponseSizeInBytes              = 1048576
+	defaultShutdownDelayInMilliseconds                 = 5000
+	defaultShutdownTimeoutInMilliseconds               = 20000
+	defaultServerReadHeaderTimeoutInMilliseconds       = 5000
+	defaultServerReadTimeoutInMilliseconds             = 10000
+	defaultServerIdleTimeoutInMilliseconds             = 60000
+	defaultTLSClientAuth                               = "require"
+	defaultTLSReloadEnable                             = false
+	defaultTLSReloadIntervalInMilliseconds             = 10000
+	defaultMetricsEnable                               = true
+	defaultReadinessUpstreamCheckEnable                = true
+	defaultReadinessCircuitBreakerCheckEnable          = false
+	defaultReadinessProbePath                          = "/version"
+	defaultReadinessProbeTTLInMilliseconds             = 10000
+	defaultReadinessProbeTimeoutInMilliseconds         = 2000
+	defaultTracingExporter                             = "none"
+	defaultTracingOTLPEndpoint                         = "localhost:4318"
+	defaultTracingOTLPInsecure                         = false
+	defaultTracingSampleRatio                          = 1.0
+	defaultAuditLogFile                                = ""
+	defaultAuditLogMaxSizeInBytes                      = 10485760
+	defaultAuditLogMaxAgeInDays                        = 90
+	defaultAdminListen                                 = ""
+	defaultAdminToken                                  = ""
+)
+
+var (
+	defaultRetryStatusCodes    = []int{429, 500, 502, 503, 504}
+	defaultUserIDReservedWords = []string{".", ".."}
+	defaultLogScrubFields      = []string{logging.BodyField}
 )
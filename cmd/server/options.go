@@ -1,9 +1,246 @@
 package server
 
+import "github.com/spf13/pflag"
+
 type Options struct {
-	LogLevel                      string
-	RateLimitEnable               bool
-	RateLimitCount                int
-	RateLimitWindowInMilliseconds int
-	TimeoutInMilliseconds         int
+	LogLevel                                    string
+	LogFormat                                   string
+	LogPII                                      bool
+	LogUserIDRedaction                          string
+	LogRedactionKey                             string
+	LogScrubFields                              []string
+	RateLimitEnable                             bool
+	RateLimitCount                              int
+	RateLimitWindowInMilliseconds               int
+	TimeoutInMilliseconds                       int
+	RetryMaxAttempts                            int
+	RetryBaseBackoffInMilliseconds              int
+	RetryMaxBackoffInMilliseconds               int
+	RetryStatusCodes                            []int
+	HedgingEnable                               bool
+	HedgingPercentile                           float64
+	HedgingDelayInMilliseconds                  int
+	HedgingBudgetPercent                        float64
+	CircuitBreakerEnable                        bool
+	CircuitBreakerFailureThreshold              int
+	CircuitBreakerCoolDownInMilliseconds        int
+	CircuitBreakerHalfOpenMaxRequests           int
+	CoalescingEnable                            bool
+	CacheEnable                                 bool
+	CacheSize                                   int
+	CacheTTLInMilliseconds                      int
+	MaxMessageLength                            int
+	MaxSubtitleLength                           int
+	UserIDMaxLength                             int
+	UserIDCharset                               string
+	UserIDReservedWords                         []string
+	UpstreamBaseURL                             string
+	UpstreamProxyURL                            string
+	UpstreamCABundleFile                        string
+	UpstreamClientCertFile                      string
+	UpstreamClientKeyFile                       string
+	UpstreamTLSMinVersion                       string
+	UpstreamMaxIdleConnections                  int
+	UpstreamMaxIdleConnectionsPerHost           int
+	UpstreamIdleConnectionTimeoutInMilliseconds int
+	UpstreamDialTimeoutInMilliseconds           int
+	UpstreamKeepAliveInMilliseconds             int
+	UpstreamTLSHandshakeTimeoutInMilliseconds   int
+	UpstreamResponseHeaderTimeoutInMilliseconds int
+	UpstreamHTTP2Enable                         bool
+	UpstreamKeepAliveEnable                     bool
+	UpstreamMaxResponseSizeInBytes              int64
+	ShutdownDelayInMilliseconds                 int
+	ShutdownTimeoutInMilliseconds               int
+	ServerReadHeaderTimeoutInMilliseconds       int
+	ServerReadTimeoutInMilliseconds             int
+	ServerIdleTimeoutInMilliseconds             int
+	Listen                                      string
+	TLSCert                                     string
+	TLSKey                                      string
+	TLSClientCAFile                             string
+	TLSClientAuth                               string
+	TLSReloadEnable                             bool
+	TLSReloadIntervalInMilliseconds             int
+	MetricsEnable                               bool
+	ReadinessUpstreamCheckEnable                bool
+	ReadinessCircuitBreakerCheckEnable          bool
+	ReadinessProbePath                          string
+	ReadinessProbeTTLInMilliseconds             int
+	ReadinessProbeTimeoutInMilliseconds         int
+	TracingExporter                             string
+	TracingOTLPEndpoint                         string
+	TracingOTLPInsecure                         bool
+	TracingSampleRatio                          float64
+	AuditLogFile                                string
+	AuditLogMaxSizeInBytes                      int64
+	AuditLogMaxAgeInDays                        int
+	AdminListen                                 string
+	AdminToken                                  string
+}
+
+// AddFlags adds a flag per option, which is also the key of the option in the config file, see LoadConfig.
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.LogLevel, "log-level", defaultLogLevel, "log leve to use")
+	flags.StringVar(&o.LogFormat, "log-format", defaultLogFormat, "format of the logs, json or text")
+	flags.BoolVar(&o.LogPII, "log-pii", defaultLogPII, "switch to log the user IDs and the scrubbed fields "+
+		"as they are")
+	flags.StringVar(&o.LogUserIDRedaction, "log-user-id-redaction", defaultLogUserIDRedaction, "redaction of "+
+		"the user IDs in the logs, hash or mask")
+	flags.StringVar(&o.LogRedactionKey, "log-redaction-key", defaultLogRedactionKey, "key of the hashes of "+
+		"the user IDs, random when it's empty")
+	flags.StringSliceVar(&o.LogScrubFields, "log-scrub-fields", defaultLogScrubFields, "fields whose values "+
+		"are removed from the logs")
+	flags.BoolVar(&o.RateLimitEnable, "rate-limit-enable", defaultRateLimitEnable, "switch to enable rate limiter")
+	flags.IntVar(&o.RateLimitCount, "rate-limit-count", defaultRateLimitCount, "maximum quantity of requests "+
+		"that a user can do in a window of time")
+	flags.IntVar(&o.RateLimitWindowInMilliseconds, "rate-limit-window-in-milliseconds", defaultRateLimitWindowInMilliseconds,
+		"window of time in milliseconds to limit the quantity of requests that a user can do")
+	flags.IntVar(&o.TimeoutInMilliseconds, "timeout-in-milliseconds", defaultTimeoutInMilliseconds,
+		"timeout of the api calls")
+	flags.IntVar(&o.RetryMaxAttempts, "retry-max-attempts", defaultRetryMaxAttempts,
+		"maximum quantity of attempts of a foaas call, including the first one")
+	flags.IntVar(&o.RetryBaseBackoffInMilliseconds, "retry-base-backoff-in-milliseconds",
+		defaultRetryBaseBackoffInMilliseconds, "backoff in milliseconds after the first failed attempt, "+
+			"it doubles after each attempt")
+	flags.IntVar(&o.RetryMaxBackoffInMilliseconds, "retry-max-backoff-in-milliseconds",
+		defaultRetryMaxBackoffInMilliseconds, "maximum backoff in milliseconds between two attempts")
+	flags.IntSliceVar(&o.RetryStatusCodes, "retry-status-codes", defaultRetryStatusCodes,
+		"status codes of foaas that are retried")
+	flags.BoolVar(&o.HedgingEnable, "hedging-enable", defaultHedgingEnable, "switch to send a second "+
+		"foaas request when the first one is slow, keeping the fastest response")
+	flags.Float64Var(&o.HedgingPercentile, "hedging-percentile", defaultHedgingPercentile,
+		"percentile of the observed foaas latencies after which a request is hedged")
+	flags.IntVar(&o.HedgingDelayInMilliseconds, "hedging-delay-in-milliseconds",
+		defaultHedgingDelayInMilliseconds, "delay in milliseconds after which a request is hedged, until "+
+			"there are enough latencies to compute the percentile")
+	flags.Float64Var(&o.HedgingBudgetPercent, "hedging-budget-percent", defaultHedgingBudgetPercent,
+		"maximum percentage of foaas requests that can be hedged")
+	flags.BoolVar(&o.CircuitBreakerEnable, "circuit-breaker-enable", defaultCircuitBreakerEnable,
+		"switch to fail fast while foaas is failing")
+	flags.IntVar(&o.CircuitBreakerFailureThreshold, "circuit-breaker-failure-threshold",
+		defaultCircuitBreakerFailureThreshold, "quantity of consecutive failed foaas calls that opens the circuit")
+	flags.IntVar(&o.CircuitBreakerCoolDownInMilliseconds, "circuit-breaker-cool-down-in-milliseconds",
+		defaultCircuitBreakerCoolDownInMilliseconds, "time in milliseconds that the circuit stays open before "+
+			"trying foaas again")
+	flags.IntVar(&o.CircuitBreakerHalfOpenMaxRequests, "circuit-breaker-half-open-max-requests",
+		defaultCircuitBreakerHalfOpenMaxRequests, "quantity of trial foaas calls that must succeed to close "+
+			"the circuit again")
+	flags.BoolVar(&o.CoalescingEnable, "coalescing-enable", defaultCoalescingEnable, "switch to share "+
+		"a single foaas call between concurrent requests for the same operation and user")
+	flags.BoolVar(&o.CacheEnable, "cache-enable", defaultCacheEnable, "switch to enable the cache of "+
+		"the foaas responses")
+	flags.IntVar(&o.CacheSize, "cache-size", defaultCacheSize, "maximum quantity of responses kept "+
+		"in the cache")
+	flags.IntVar(&o.CacheTTLInMilliseconds, "cache-ttl-in-milliseconds", defaultCacheTTLInMilliseconds,
+		"time in milliseconds that a cached response is fresh, stale responses are only served when foaas fails")
+	flags.IntVar(&o.MaxMessageLength, "max-message-length", defaultMaxMessageLength,
+		"maximum quantity of characters of the foaas messages, longer ones are rejected")
+	flags.IntVar(&o.MaxSubtitleLength, "max-subtitle-length", defaultMaxSubtitleLength,
+		"maximum quantity of characters of the foaas subtitles, longer ones are rejected")
+	flags.IntVar(&o.UserIDMaxLength, "user-id-max-length", defaultUserIDMaxLength,
+		"maximum quantity of characters of the user IDs")
+	flags.StringVar(&o.UserIDCharset, "user-id-charset", defaultUserIDCharset, "characters allowed "+
+		"in the user IDs, as the content of a regular expression character class")
+	flags.StringSliceVar(&o.UserIDReservedWords, "user-id-reserved-words", defaultUserIDReservedWords,
+		"user IDs that are rejected, ignoring the case")
+	flags.StringVar(&o.UpstreamBaseURL, "upstream-base-url", defaultUpstreamBaseURL, "base url of "+
+		"foaas, the operations are requested under its path")
+	flags.StringVar(&o.UpstreamProxyURL, "upstream-proxy-url", "", "url of the proxy to reach foaas, "+
+		"by default the one of the HTTP_PROXY and HTTPS_PROXY environment variables")
+	flags.StringVar(&o.UpstreamCABundleFile, "upstream-ca-bundle-file", "", "PEM file with the CA "+
+		"certificates trusted to reach foaas, besides the ones of the system")
+	flags.StringVar(&o.UpstreamClientCertFile, "upstream-client-cert-file", "", "PEM file with the "+
+		"client certificate presented to foaas")
+	flags.StringVar(&o.UpstreamClientKeyFile, "upstream-client-key-file", "", "PEM file with the "+
+		"key of the client certificate presented to foaas")
+	flags.StringVar(&o.UpstreamTLSMinVersion, "upstream-tls-min-version", defaultUpstreamTLSMinVersion,
+		"minimum TLS version to reach foaas, it can be 1.0, 1.1, 1.2 or 1.3")
+	flags.IntVar(&o.UpstreamMaxIdleConnections, "upstream-max-idle-connections",
+		defaultUpstreamMaxIdleConnections, "maximum quantity of idle connections kept open to reach foaas")
+	flags.IntVar(&o.UpstreamMaxIdleConnectionsPerHost, "upstream-max-idle-connections-per-host",
+		defaultUpstreamMaxIdleConnectionsPerHost, "maximum quantity of idle connections kept open per foaas host")
+	flags.IntVar(&o.UpstreamIdleConnectionTimeoutInMilliseconds,
+		"upstream-idle-connection-timeout-in-milliseconds", defaultUpstreamIdleConnectionTimeoutInMilliseconds,
+		"time in milliseconds that an idle connection to foaas is kept open")
+	flags.IntVar(&o.UpstreamDialTimeoutInMilliseconds, "upstream-dial-timeout-in-milliseconds",
+		defaultUpstreamDialTimeoutInMilliseconds, "timeout in milliseconds to open a connection to foaas")
+	flags.IntVar(&o.UpstreamKeepAliveInMilliseconds, "upstream-keep-alive-in-milliseconds",
+		defaultUpstreamKeepAliveInMilliseconds, "interval in milliseconds between the TCP keep-alive probes of "+
+			"the connections to foaas")
+	flags.IntVar(&o.UpstreamTLSHandshakeTimeoutInMilliseconds,
+		"upstream-tls-handshake-timeout-in-milliseconds", defaultUpstreamTLSHandshakeTimeoutInMilliseconds,
+		"timeout in milliseconds of the TLS handshake with foaas")
+	flags.IntVar(&o.UpstreamResponseHeaderTimeoutInMilliseconds,
+		"upstream-response-header-timeout-in-milliseconds", defaultUpstreamResponseHeaderTimeoutInMilliseconds,
+		"timeout in milliseconds to receive the headers of foaas after sending a request, 0 means no timeout "+
+			"besides the one of the request")
+	flags.BoolVar(&o.UpstreamHTTP2Enable, "upstream-http2-enable", defaultUpstreamHTTP2Enable,
+		"switch to use HTTP/2 with foaas when it supports it")
+	flags.BoolVar(&o.UpstreamKeepAliveEnable, "upstream-keep-alive-enable",
+		defaultUpstreamKeepAliveEnable, "switch to reuse the connections to foaas between requests")
+	flags.Int64Var(&o.UpstreamMaxResponseSizeInBytes, "upstream-max-response-size-in-bytes",
+		defaultUpstreamMaxResponseSizeInBytes, "maximum size in bytes of the bodies of foaas")
+	flags.IntVar(&o.ShutdownDelayInMilliseconds, "shutdown-delay-in-milliseconds",
+		defaultShutdownDelayInMilliseconds, "time in milliseconds the server keeps serving on shutdown after "+
+			"failing the health checks")
+	flags.IntVar(&o.ShutdownTimeoutInMilliseconds, "shutdown-timeout-in-milliseconds",
+		defaultShutdownTimeoutInMilliseconds, "timeout in milliseconds to drain the in-flight requests on "+
+			"shutdown, including the delay")
+	flags.IntVar(&o.ServerReadHeaderTimeoutInMilliseconds, "server-read-header-timeout-in-milliseconds",
+		defaultServerReadHeaderTimeoutInMilliseconds, "timeout in milliseconds to read the headers of a request")
+	flags.IntVar(&o.ServerReadTimeoutInMilliseconds, "server-read-timeout-in-milliseconds",
+		defaultServerReadTimeoutInMilliseconds, "timeout in milliseconds to read a whole request, including "+
+			"its body")
+	flags.IntVar(&o.ServerIdleTimeoutInMilliseconds, "server-idle-timeout-in-milliseconds",
+		defaultServerIdleTimeoutInMilliseconds, "time in milliseconds that an idle keep-alive connection is "+
+			"kept open")
+	flags.StringVar(&o.Listen, "listen", defaultListen, "address the server listens on, like "+
+		":4000 or 127.0.0.1:4000")
+	flags.StringVar(&o.TLSCert, "tls-cert", "", "PEM file of the certificate to serve HTTPS")
+	flags.StringVar(&o.TLSKey, "tls-key", "", "PEM file of the key of the certificate to serve HTTPS")
+	flags.StringVar(&o.TLSClientCAFile, "tls-client-ca-file", "", "PEM file with the CA "+
+		"certificates that sign the client certificates, which are verified when it's set")
+	flags.StringVar(&o.TLSClientAuth, "tls-client-auth", defaultTLSClientAuth, "whether the "+
+		"client certificates are required or only verified when they are given: require, verify-if-given")
+	flags.BoolVar(&o.TLSReloadEnable, "tls-reload-enable", defaultTLSReloadEnable,
+		"switch to reload the certificate when its files change")
+	flags.IntVar(&o.TLSReloadIntervalInMilliseconds, "tls-reload-interval-in-milliseconds",
+		defaultTLSReloadIntervalInMilliseconds, "interval in milliseconds between the checks of the files of "+
+			"the certificate")
+	flags.BoolVar(&o.MetricsEnable, "metrics-enable", defaultMetricsEnable, "switch to serve the metrics in "+
+		"the Prometheus format in /metrics")
+	flags.BoolVar(&o.ReadinessUpstreamCheckEnable, "readiness-upstream-check-enable",
+		defaultReadinessUpstreamCheckEnable, "switch to fail the readiness when foaas is not reachable")
+	flags.BoolVar(&o.ReadinessCircuitBreakerCheckEnable, "readiness-circuit-breaker-check-enable",
+		defaultReadinessCircuitBreakerCheckEnable, "switch to fail the readiness while the circuit breaker is "+
+			"open")
+	flags.StringVar(&o.ReadinessProbePath, "readiness-probe-path", defaultReadinessProbePath, "path under the "+
+		"upstream base url requested to check that foaas is reachable")
+	flags.IntVar(&o.ReadinessProbeTTLInMilliseconds, "readiness-probe-ttl-in-milliseconds",
+		defaultReadinessProbeTTLInMilliseconds, "time in milliseconds the result of the probe of foaas is cached")
+	flags.IntVar(&o.ReadinessProbeTimeoutInMilliseconds, "readiness-probe-timeout-in-milliseconds",
+		defaultReadinessProbeTimeoutInMilliseconds, "timeout in milliseconds of the probe of foaas")
+	flags.StringVar(&o.TracingExporter, "tracing-exporter", defaultTracingExporter, "exporter of the traces, "+
+		"none, stdout or otlp")
+	flags.StringVar(&o.TracingOTLPEndpoint, "tracing-otlp-endpoint", defaultTracingOTLPEndpoint, "host and port "+
+		"of the OTLP HTTP collector of the traces")
+	flags.BoolVar(&o.TracingOTLPInsecure, "tracing-otlp-insecure", defaultTracingOTLPInsecure,
+		"switch to send the traces to the OTLP collector without TLS")
+	flags.Float64Var(&o.TracingSampleRatio, "tracing-sample-ratio", defaultTracingSampleRatio, "ratio of the "+
+		"traces sampled, from 0 to 1, when the caller didn't sample them already")
+	flags.StringVar(&o.AuditLogFile, "audit-log-file", defaultAuditLogFile, "JSON lines file of the audit "+
+		"events, like the rate limit denials and the configuration reloads, the audit is disabled when it's empty")
+	flags.Int64Var(&o.AuditLogMaxSizeInBytes, "audit-log-max-size-in-bytes", defaultAuditLogMaxSizeInBytes,
+		"size in bytes at which the audit log is rotated, 0 means no rotation")
+	flags.IntVar(&o.AuditLogMaxAgeInDays, "audit-log-max-age-in-days", defaultAuditLogMaxAgeInDays, "days the "+
+		"rotated audit logs are kept, 0 means forever")
+	flags.StringVar(&o.AdminListen, "admin-listen", defaultAdminListen, "address of the admin listener, like "+
+		"127.0.0.1:6060, which serves pprof, the runtime stats, the build info and the config, it's disabled "+
+		"when it's empty")
+	flags.StringVar(&o.AdminToken, "admin-token", defaultAdminToken, "bearer token required by the admin "+
+		"listener")
+	markSecret(flags, "upstream-proxy-url")
+	markSecret(flags, "log-redaction-key")
+	markSecret(flags, "admin-token")
 }
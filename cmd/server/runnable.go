@@ -1,13 +1,29 @@
 package server
 
 import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/admin"
+	"github.com/hortelanobruno/foaas-api/audit"
+	"github.com/hortelanobruno/foaas-api/cache"
 	"github.com/hortelanobruno/foaas-api/domain/service"
 	"github.com/hortelanobruno/foaas-api/domain/service/handler"
 	"github.com/hortelanobruno/foaas-api/domain/validator"
 	"github.com/hortelanobruno/foaas-api/http"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"github.com/hortelanobruno/foaas-api/metrics"
 	"github.com/hortelanobruno/foaas-api/ratelimiter"
+	"github.com/hortelanobruno/foaas-api/tracing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -26,45 +42,252 @@ func (r *Runnable) Cmd() *cobra.Command {
 		Long:  `Runs foaas API`,
 	}
 
-	cmd.Flags().StringVar(&options.LogLevel, "log-level", defaultLogLevel, "log leve to use")
-	cmd.Flags().BoolVar(&options.RateLimitEnable, "rate-limit-enable", defaultRateLimitEnable, "switch to enable rate limiter")
-	cmd.Flags().IntVar(&options.RateLimitCount, "rate-limit-count", defaultRateLimitCount, "maximum quantity of requests "+
-		"that a user can do in a window of time")
-	cmd.Flags().IntVar(&options.RateLimitWindowInMilliseconds, "rate-limit-window-in-milliseconds", defaultRateLimitWindowInMilliseconds,
-		"window of time in milliseconds to limit the quantity of requests that a user can do")
-	cmd.Flags().IntVar(&options.TimeoutInMilliseconds, "timeout-in-milliseconds", defaultTimeoutInMilliseconds,
-		"timeout of the api calls")
+	options.AddFlags(cmd.Flags())
+	AddConfigFlag(cmd.Flags())
 
-	cmd.Run = func(_ *cobra.Command, _ []string) {
-		server := r.Run(options)
-		server.Start(defaultPort)
+	cmd.Run = func(cmd *cobra.Command, _ []string) {
+		if err := LoadConfig(cmd.Flags()); err != nil {
+			logrus.Fatalf("Error loading the configuration, err: %s", err.Error())
+		}
+		if err := options.Validate(); err != nil {
+			logrus.Fatalf("Error validating the configuration, err: %s", err.Error())
+		}
+		server := r.Run(options, cmd.Flags())
+		if err := server.Start(options.Listen); err != nil {
+			logrus.Fatalf("Error starting the server, err: %s", err.Error())
+		}
+		r.waitForShutdown(server, time.Duration(options.ShutdownTimeoutInMilliseconds)*time.Millisecond)
 	}
 	return cmd
 }
 
-func (r *Runnable) Run(options *Options) *Server {
-	r.configureLog(options.LogLevel)
+// waitForShutdown shuts the server down gracefully on SIGINT or SIGTERM.
+func (r *Runnable) waitForShutdown(server *Server, timeout time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
 
+	select {
+	case sig := <-signals:
+		logrus.Infof("Receiving signal %s", sig)
+	case err := <-server.Errors():
+		logrus.Fatalf("Error running the server, err: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logrus.Errorf("Error shutting down the server, err: %s", err.Error())
+	}
+	if err := tracing.Shutdown(ctx); err != nil {
+		logrus.Errorf("Error flushing the spans, err: %s", err.Error())
+	}
+}
+
+// Run builds the server of the options, whose flags are dumped by the admin listener as the effective
+// configuration.
+func (r *Runnable) Run(options *Options, flags *pflag.FlagSet) *Server {
+	r.configureLog(options)
+
+	var serverMetrics *metrics.Metrics
+	if options.MetricsEnable {
+		serverMetrics = metrics.NewMetrics()
+	}
+
+	var auditor *audit.Auditor
+	if options.AuditLogFile != "" {
+		auditFile, err := audit.OpenRotatingFile(options.AuditLogFile, options.AuditLogMaxSizeInBytes,
+			time.Duration(options.AuditLogMaxAgeInDays)*24*time.Hour)
+		if err != nil {
+			logrus.Fatalf("Error opening the audit log, err: %s", err.Error())
+		}
+		auditor = audit.NewAuditor(auditFile)
+	}
+
+	var healthChecks []handler.HealthCheck
 	var rateLimiter ratelimiter.RateLimiter
 	if options.RateLimitEnable {
-		rateLimiter = ratelimiter.NewLocalRateLimiter(
+		localRateLimiter := ratelimiter.NewLocalRateLimiter(
 			options.RateLimitCount,
-			time.Duration(options.RateLimitWindowInMilliseconds)*time.Millisecond)
+			time.Duration(options.RateLimitWindowInMilliseconds)*time.Millisecond,
+			ratelimiter.WithQuotaExhaustedListener(func(userID string) {
+				auditor.Record(audit.Event{
+					Type:   audit.EventQuotaExhausted,
+					UserID: userID,
+					Details: map[string]string{
+						"rate_limit_count":                  strconv.Itoa(options.RateLimitCount),
+						"rate_limit_window_in_milliseconds": strconv.Itoa(options.RateLimitWindowInMilliseconds),
+					},
+				})
+			}))
+		rateLimiter = localRateLimiter
+		healthChecks = append(healthChecks, handler.HealthCheck{Name: "rateLimiter",
+			Check: localRateLimiter.CheckHealth})
+		if serverMetrics != nil {
+			serverMetrics.WatchRateLimiter(localRateLimiter.TrackedUsers)
+			rateLimiter = serverMetrics.ObservedRateLimiter(localRateLimiter)
+		}
+	}
+
+	baseURL, err := url.Parse(options.UpstreamBaseURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		logrus.Fatalf("Error parsing the upstream base url: %s", options.UpstreamBaseURL)
+	}
+	transport, err := http.NewTransport(http.TransportConfig{
+		ProxyURL:            options.UpstreamProxyURL,
+		CABundleFile:        options.UpstreamCABundleFile,
+		ClientCertFile:      options.UpstreamClientCertFile,
+		ClientKeyFile:       options.UpstreamClientKeyFile,
+		TLSMinVersion:       options.UpstreamTLSMinVersion,
+		MaxIdleConns:        options.UpstreamMaxIdleConnections,
+		MaxIdleConnsPerHost: options.UpstreamMaxIdleConnectionsPerHost,
+		IdleConnTimeout:     time.Duration(options.UpstreamIdleConnectionTimeoutInMilliseconds) * time.Millisecond,
+		DialTimeout:         time.Duration(options.UpstreamDialTimeoutInMilliseconds) * time.Millisecond,
+		KeepAlive:           time.Duration(options.UpstreamKeepAliveInMilliseconds) * time.Millisecond,
+		TLSHandshakeTimeout: time.Duration(options.UpstreamTLSHandshakeTimeoutInMilliseconds) * time.Millisecond,
+		ResponseHeaderTimeout: time.Duration(options.UpstreamResponseHeaderTimeoutInMilliseconds) *
+			time.Millisecond,
+		DisableHTTP2:      !options.UpstreamHTTP2Enable,
+		DisableKeepAlives: !options.UpstreamKeepAliveEnable,
+	})
+	if err != nil {
+		logrus.Fatalf("Error building the upstream transport, err: %s", err.Error())
+	}
+
+	clientOptions := []http.ClientOption{
+		http.WithTransport(transport),
+		http.WithMaxResponseSize(options.UpstreamMaxResponseSizeInBytes),
+		http.WithRetryPolicy(http.RetryPolicy{
+			MaxAttempts:          options.RetryMaxAttempts,
+			BaseBackoff:          time.Duration(options.RetryBaseBackoffInMilliseconds) * time.Millisecond,
+			MaxBackoff:           time.Duration(options.RetryMaxBackoffInMilliseconds) * time.Millisecond,
+			RetryableStatusCodes: options.RetryStatusCodes,
+		}),
+		http.WithHedgingPolicy(http.HedgingPolicy{
+			Enable:        options.HedgingEnable,
+			Percentile:    options.HedgingPercentile,
+			Delay:         time.Duration(options.HedgingDelayInMilliseconds) * time.Millisecond,
+			BudgetPercent: options.HedgingBudgetPercent,
+		}),
+	}
+	// The checks of foaas can be turned off, since they make every replica unready at once when foaas is
+	// down, even though the stale responses of the cache could still be served.
+	if options.ReadinessUpstreamCheckEnable {
+		probeURL := *baseURL
+		probeURL.Path = strings.TrimSuffix(baseURL.Path, "/") + options.ReadinessProbePath
+		probeTimeout := time.Duration(options.ReadinessProbeTimeoutInMilliseconds) * time.Millisecond
+		probe := http.NewProbe(http.NewClientImpl(probeTimeout, http.WithTransport(transport)), probeURL.String(),
+			time.Duration(options.ReadinessProbeTTLInMilliseconds)*time.Millisecond, probeTimeout)
+		healthChecks = append(healthChecks, handler.HealthCheck{Name: "upstream", Check: probe.Check})
+	}
+
+	var serverOptions []ServerOption
+	if serverMetrics != nil {
+		serverMetrics.WatchConnectionPool(transport.Stats)
+		clientOptions = append(clientOptions, http.WithAttemptObserver(serverMetrics))
+		serverOptions = append(serverOptions, WithMetrics(serverMetrics))
+	}
+	var httpClient http.Client = http.NewClientImpl(time.Duration(options.TimeoutInMilliseconds)*time.Millisecond,
+		clientOptions...)
+	var circuitBreaker *http.CircuitBreakerClient
+	if options.CircuitBreakerEnable {
+		circuitBreaker = http.NewCircuitBreakerClient(httpClient,
+			options.CircuitBreakerFailureThreshold,
+			time.Duration(options.CircuitBreakerCoolDownInMilliseconds)*time.Millisecond,
+			options.CircuitBreakerHalfOpenMaxRequests)
+		httpClient = circuitBreaker
+		if options.ReadinessCircuitBreakerCheckEnable {
+			healthChecks = append(healthChecks, handler.NewCircuitBreakerCheck(circuitBreaker))
+		}
 	}
 
-	httpClient := http.NewClientImpl(time.Duration(options.TimeoutInMilliseconds) * time.Millisecond)
+	responseValidator := validator.NewResponseValidatorImpl(options.MaxMessageLength, options.MaxSubtitleLength)
+	var messageService service.MessageService = service.NewMessageServiceImpl(baseURL, httpClient,
+		responseValidator)
+	if options.CoalescingEnable {
+		messageService = service.NewCoalescedMessageService(messageService)
+	}
+	if options.CacheEnable {
+		cachedService := service.NewCachedMessageService(messageService,
+			cache.NewLocalCache(options.CacheSize, time.Duration(options.CacheTTLInMilliseconds)*time.Millisecond))
+		if serverMetrics != nil {
+			serverMetrics.WatchCache(cachedService.Stats)
+		}
+		messageService = cachedService
+	}
 
-	messageService := service.NewMessageServiceImpl(httpClient)
-	messageValidator := validator.NewMessageValidatorImpl()
+	charsetRule, err := validator.NewCharsetRule(options.UserIDCharset)
+	if err != nil {
+		logrus.Fatalf("Error building the user ID validator, err: %s", err.Error())
+	}
+	messageValidator := validator.NewMessageValidatorImpl(
+		validator.NewMaxLengthRule(options.UserIDMaxLength),
+		charsetRule,
+		validator.NewReservedWordsRule(options.UserIDReservedWords))
 	messageHandler := handler.NewMessageHandler(messageValidator, messageService)
+	healthHandler := handler.NewHealthHandler(circuitBreaker, healthChecks...)
 
-	return NewServer(messageHandler, rateLimiter)
+	tlsConfig, err := NewTLSConfig(TLSConfig{
+		CertFile:       options.TLSCert,
+		KeyFile:        options.TLSKey,
+		ClientCAFile:   options.TLSClientCAFile,
+		ClientAuth:     options.TLSClientAuth,
+		ReloadEnable:   options.TLSReloadEnable,
+		ReloadInterval: time.Duration(options.TLSReloadIntervalInMilliseconds) * time.Millisecond,
+		Auditor:        auditor,
+	})
+	if err != nil {
+		logrus.Fatalf("Error building the TLS configuration, err: %s", err.Error())
+	}
+
+	tracerProvider, err := tracing.Setup(context.Background(), options.tracingConfig())
+	if err != nil {
+		logrus.Fatalf("Error setting up the tracing, err: %s", err.Error())
+	}
+	if tracerProvider != nil {
+		serverOptions = append(serverOptions, WithTracing(tracerProvider))
+	}
+
+	if options.AdminListen != "" {
+		serverOptions = append(serverOptions, WithAdmin(options.AdminListen, admin.NewHandler(options.AdminToken,
+			func(writer io.Writer) error {
+				return PrintConfig(writer, flags)
+			}, auditor)))
+	}
+
+	serverOptions = append(serverOptions,
+		WithAudit(auditor),
+		WithShutdownDelay(time.Duration(options.ShutdownDelayInMilliseconds)*time.Millisecond),
+		WithTimeouts(time.Duration(options.ServerReadHeaderTimeoutInMilliseconds)*time.Millisecond,
+			time.Duration(options.ServerReadTimeoutInMilliseconds)*time.Millisecond,
+			time.Duration(options.ServerIdleTimeoutInMilliseconds)*time.Millisecond),
+		WithTLS(tlsConfig))
+	return NewServer(messageHandler, healthHandler, rateLimiter, serverOptions...)
 }
 
-func (r *Runnable) configureLog(logLevel string) {
-	lvl, err := logrus.ParseLevel(logLevel)
+// configureLog redacts the user IDs and scrubs the fields of the logs, unless the PII is logged on purpose.
+func (r *Runnable) configureLog(options *Options) {
+	formatter, err := logging.NewFormatter(options.LogFormat)
+	if err != nil {
+		logrus.Warnf("Error parsing the log format: %s", options.LogFormat)
+		formatter = logrus.StandardLogger().Formatter
+	}
+	if !options.LogPII {
+		redactor, err := logging.NewRedactor(options.LogUserIDRedaction, options.LogRedactionKey,
+			options.LogScrubFields)
+		if err != nil {
+			logrus.Fatalf("Error building the log redactor, err: %s", err.Error())
+		}
+		formatter = redactor.Formatter(formatter)
+	}
+	logrus.SetFormatter(formatter)
+	// The messages of gin, like its routes and the panics it recovers, go to the logs too.
+	gin.DefaultWriter = logrus.StandardLogger().WriterLevel(logrus.DebugLevel)
+	gin.DefaultErrorWriter = logrus.StandardLogger().WriterLevel(logrus.ErrorLevel)
+
+	lvl, err := logrus.ParseLevel(options.LogLevel)
 	if err != nil {
-		logrus.Warnf("Error passing the log level: %s", logLevel)
+		logrus.Warnf("Error passing the log level: %s", options.LogLevel)
 		return
 	}
 
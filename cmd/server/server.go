@@ -1,38 +1,276 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/audit"
 	"github.com/hortelanobruno/foaas-api/domain/service/handler"
+	"github.com/hortelanobruno/foaas-api/metrics"
 	"github.com/hortelanobruno/foaas-api/middleware"
 	"github.com/hortelanobruno/foaas-api/ratelimiter"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+const serviceName = "foaas-api"
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// untracedPaths are the paths of the health checks and the metrics, which are requested too often to trace.
+var untracedPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
 type Server struct {
 	messageHandler *handler.MessageHandler
+	healthHandler  *handler.HealthHandler
 	rateLimiter    ratelimiter.RateLimiter
+	shutdownDelay  time.Duration
+	timeouts       timeouts
+	tlsConfig      *tls.Config
+	metrics        *metrics.Metrics
+	tracerProvider trace.TracerProvider
+	auditor        *audit.Auditor
+	adminAddr      string
+	adminHandler   http.Handler
+	httpServer     *http.Server
+	listener       net.Listener
+	adminServer    *http.Server
+	adminListener  net.Listener
+	errs           chan error
+	shuttingDown   int32
+}
+
+// timeouts bound the time the clients can hold a connection, so that slow clients can't exhaust the
+// connections of the server.
+type timeouts struct {
+	readHeader time.Duration
+	read       time.Duration
+	idle       time.Duration
+}
+
+type ServerOption func(*Server)
+
+// WithShutdownDelay keeps serving requests for a while after the readiness is flipped on shutdown, to let
+// the load balancers stop sending new requests before draining the in-flight ones.
+func WithShutdownDelay(shutdownDelay time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownDelay = shutdownDelay
+	}
 }
 
-func NewServer(messageHandler *handler.MessageHandler, rateLimiter ratelimiter.RateLimiter) *Server {
-	return &Server{
+// WithTimeouts bounds the time to read the headers and the whole of a request, and the time an idle
+// keep-alive connection is kept open.
+func WithTimeouts(readHeaderTimeout, readTimeout, idleTimeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.timeouts = timeouts{readHeader: readHeaderTimeout, read: readTimeout, idle: idleTimeout}
+	}
+}
+
+// WithTLS serves HTTPS with the configuration, see NewTLSConfig.
+func WithTLS(tlsConfig *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConfig = tlsConfig
+	}
+}
+
+// WithMetrics gathers the metrics of the requests and serves them in /metrics.
+func WithMetrics(metrics *metrics.Metrics) ServerOption {
+	return func(s *Server) {
+		s.metrics = metrics
+	}
+}
+
+// WithTracing traces the requests, except the ones of the health checks and the metrics.
+func WithTracing(tracerProvider trace.TracerProvider) ServerOption {
+	return func(s *Server) {
+		s.tracerProvider = tracerProvider
+	}
+}
+
+// WithAudit records the requests denied by the rate limiter in the audit.
+func WithAudit(auditor *audit.Auditor) ServerOption {
+	return func(s *Server) {
+		s.auditor = auditor
+	}
+}
+
+// WithAdmin serves the admin handler, see admin.NewHandler, on its own address, apart from the public
+// endpoints. It's served without TLS, so the address should only be reachable by the operators.
+func WithAdmin(addr string, adminHandler http.Handler) ServerOption {
+	return func(s *Server) {
+		s.adminAddr = addr
+		s.adminHandler = adminHandler
+	}
+}
+
+func NewServer(messageHandler *handler.MessageHandler, healthHandler *handler.HealthHandler,
+	rateLimiter ratelimiter.RateLimiter, options ...ServerOption) *Server {
+	server := &Server{
 		messageHandler: messageHandler,
 		rateLimiter:    rateLimiter,
+		errs:           make(chan error, 1),
+	}
+	for _, option := range options {
+		option(server)
 	}
+	server.healthHandler = healthHandler.WithChecks(handler.HealthCheck{Name: "shutdown",
+		Check: server.checkShutdown})
+	return server
 }
 
-func (s *Server) Start(port int) {
-	engine := gin.Default()
+// Start listens on the address, like :4000 or 127.0.0.1:4000, where the port is chosen by the system when
+// it's 0, and serves the requests in the background until Shutdown is called.
+func (s *Server) Start(addr string) error {
+	engine := gin.New()
+	if s.tracerProvider != nil {
+		engine.Use(otelgin.Middleware(serviceName, otelgin.WithTracerProvider(s.tracerProvider),
+			otelgin.WithFilter(isTraced)))
+	}
+	engine.Use(middleware.RequestID(), middleware.Logger())
+	if s.metrics != nil {
+		// Before the recovery, to observe the requests that panic as server errors.
+		engine.Use(middleware.Metrics(s.metrics))
+	}
+	engine.Use(gin.Recovery())
+	s.attachEndpoints(engine)
 
-	if s.rateLimiter != nil {
-		engine.Use(middleware.RateLimiter(s.rateLimiter))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening on %s, err: %s", addr, err.Error())
 	}
+	scheme := "http"
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+		scheme = "https"
+	}
+	s.listener = listener
+	s.httpServer = s.newHTTPServer(engine)
+	s.httpServer.TLSConfig = s.tlsConfig
 
-	s.attachEndpoints(engine)
-	if err := engine.Run(fmt.Sprintf(":%d", port)); err != nil {
-		panic(err)
+	if s.adminHandler != nil {
+		if err := s.startAdmin(); err != nil {
+			_ = listener.Close()
+			return err
+		}
 	}
+
+	logrus.Infof("Listening on %s://%s", scheme, s.Addr())
+	go s.serve(s.httpServer, listener)
+	return nil
+}
+
+func (s *Server) startAdmin() error {
+	adminListener, err := net.Listen("tcp", s.adminAddr)
+	if err != nil {
+		return fmt.Errorf("error listening on %s for the admin, err: %s", s.adminAddr, err.Error())
+	}
+	s.adminListener = adminListener
+	s.adminServer = s.newHTTPServer(s.adminHandler)
+
+	logrus.Infof("Listening on http://%s for the admin", s.AdminAddr())
+	go s.serve(s.adminServer, adminListener)
+	return nil
+}
+
+func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: s.timeouts.readHeader,
+		ReadTimeout:       s.timeouts.read,
+		IdleTimeout:       s.timeouts.idle,
+	}
+}
+
+func (s *Server) serve(httpServer *http.Server, listener net.Listener) {
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		select {
+		case s.errs <- fmt.Errorf("error serving on %s, err: %s", listener.Addr(), err.Error()):
+		default:
+		}
+	}
+}
+
+// Addr returns the address the server listens on, once it's started.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// AdminAddr returns the address the admin listens on, once it's started, or an empty string without admin.
+func (s *Server) AdminAddr() string {
+	if s.adminListener == nil {
+		return ""
+	}
+	return s.adminListener.Addr().String()
+}
+
+// Errors reports the error that stopped the server before it was shut down.
+func (s *Server) Errors() <-chan error {
+	return s.errs
+}
+
+// Shutdown fails the readiness check, waits for the shutdown delay and then stops accepting connections,
+// waiting for the in-flight requests until the context is done. The admin is shut down even when the
+// requests couldn't be drained.
+func (s *Server) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&s.shuttingDown, 1)
+	logrus.Infof("Shutting down, waiting %s before draining the requests", s.shutdownDelay)
+	select {
+	case <-time.After(s.shutdownDelay):
+	case <-ctx.Done():
+	}
+
+	var errs []string
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("error draining the requests, err: %s", err.Error()))
+	} else {
+		logrus.Infof("Finishing to drain the requests")
+	}
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("error shutting down the admin, err: %s", err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
 }
 
 func (s *Server) attachEndpoints(engine *gin.Engine) {
-	engine.GET("/message", s.messageHandler.HandleGetMessage)
+	if s.metrics != nil {
+		engine.GET("/metrics", gin.WrapH(s.metrics.Handler()))
+	}
+	engine.GET("/healthz", s.healthHandler.HandleHealth)
+	engine.GET("/readyz", s.healthHandler.HandleReadiness)
+
+	messages := engine.Group("/message")
+	if s.rateLimiter != nil {
+		messages.Use(middleware.RateLimiter(s.rateLimiter, s.auditor))
+	}
+	messages.GET("", s.messageHandler.HandleGetMessage)
+	messages.GET("/:operation", s.messageHandler.HandleGetMessageImage)
+}
+
+// checkShutdown fails the readiness while the server shuts down, so that it stops getting requests.
+func (s *Server) checkShutdown(_ context.Context) error {
+	if atomic.LoadInt32(&s.shuttingDown) == 1 {
+		return errShuttingDown
+	}
+	return nil
+}
+
+func isTraced(request *http.Request) bool {
+	return !untracedPaths[request.URL.Path]
 }
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/audit"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"require":         tls.RequireAndVerifyClientCert,
+	"verify-if-given": tls.VerifyClientCertIfGiven,
+}
+
+// TLSConfig configures HTTPS, which is enabled when there's a certificate. The client certificates are
+// verified when there's a client CA file.
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	ClientAuth     string
+	ReloadEnable   bool
+	ReloadInterval time.Duration
+	Auditor        *audit.Auditor
+}
+
+// NewTLSConfig builds the configuration of the listener, which is nil when HTTPS is disabled.
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+	if config.CertFile == "" && config.KeyFile == "" {
+		return nil, nil
+	}
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, fmt.Errorf("both the TLS certificate and key are needed")
+	}
+
+	reloader, err := newCertificateReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if config.ReloadEnable {
+		reloader.interval = config.ReloadInterval
+	}
+	reloader.auditor = config.Auditor
+	// The listener is wrapped before serving, so the protocols must be announced here for the clients to
+	// negotiate HTTP/2.
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	if config.ClientCAFile != "" {
+		clientAuth, exists := clientAuthTypes[config.ClientAuth]
+		if !exists {
+			return nil, fmt.Errorf("TLS client auth %q is not supported", config.ClientAuth)
+		}
+		clientCAs, err := loadClientCAs(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = clientAuth
+	}
+	return tlsConfig, nil
+}
+
+func loadClientCAs(file string) (*x509.CertPool, error) {
+	bundle, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the client CA file, err: %s", err.Error())
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("client CA file %s has no certificates", file)
+	}
+	return clientCAs, nil
+}
+
+// certificateReloader serves the certificate of the files and, when the interval isn't zero, checks at most
+// once per interval, on the handshakes, if the files changed to load them again. A certificate that fails to
+// load is ignored and the previous one keeps being served. The reloads are recorded in the audit.
+type certificateReloader struct {
+	certFile    string
+	keyFile     string
+	interval    time.Duration
+	certificate *tls.Certificate
+	modTimes    [2]time.Time
+	checkedAt   time.Time
+	auditor     *audit.Auditor
+	mutex       sync.Mutex
+	now         func() time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		now:      time.Now,
+	}
+	modTimes, err := reloader.modTimesOfFiles()
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.load(modTimes); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (c *certificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	if c.interval <= 0 || now.Sub(c.checkedAt) < c.interval {
+		return c.certificate, nil
+	}
+	c.checkedAt = now
+
+	modTimes, err := c.modTimesOfFiles()
+	if err != nil {
+		logrus.Errorf("Error checking the TLS certificate, err: %s", err.Error())
+		return c.certificate, nil
+	}
+	if modTimes != c.modTimes {
+		if err := c.load(modTimes); err != nil {
+			logrus.Errorf("Error reloading the TLS certificate, err: %s", err.Error())
+			c.recordReload(audit.EventConfigReloadFailed, map[string]string{"error": err.Error()})
+			return c.certificate, nil
+		}
+		logrus.Infof("Reloading the TLS certificate %s", c.certFile)
+		c.recordReload(audit.EventConfigReloaded, nil)
+	}
+	return c.certificate, nil
+}
+
+func (c *certificateReloader) load(modTimes [2]time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading the TLS certificate, err: %s", err.Error())
+	}
+	c.certificate = &certificate
+	c.modTimes = modTimes
+	return nil
+}
+
+func (c *certificateReloader) modTimesOfFiles() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, fmt.Errorf("error reading the TLS certificate, err: %s", err.Error())
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (c *certificateReloader) recordReload(eventType string, details map[string]string) {
+	event := audit.Event{
+		Type: eventType,
+		Details: map[string]string{
+			"component": "tls_certificate",
+			"cert_file": c.certFile,
+			"key_file":  c.keyFile,
+		},
+	}
+	for key, value := range details {
+		event.Details[key] = value
+	}
+	c.auditor.Record(event)
+}
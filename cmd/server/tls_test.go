@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/audit"
+	"github.com/hortelanobruno/foaas-api/domain/service/handler"
+	"github.com/hortelanobruno/foaas-api/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := testutil.WriteCertificate(t, dir, "server", 1)
+	emptyFile := filepath.Join(dir, "empty.pem")
+	assert.Nil(t, ioutil.WriteFile(emptyFile, []byte("empty"), 0600))
+
+	cases := []struct {
+		name           string
+		config         TLSConfig
+		expectedConfig bool
+		expectedError  error
+	}{
+		{
+			"Should return no configuration when there's no certificate",
+			TLSConfig{},
+			false,
+			nil,
+		},
+		{
+			"Should return an error when there's a certificate without key",
+			TLSConfig{CertFile: certFile},
+			false,
+			fmt.Errorf("both the TLS certificate and key are needed"),
+		},
+		{
+			"Should return an error when the certificate can't be loaded",
+			TLSConfig{CertFile: emptyFile, KeyFile: keyFile},
+			false,
+			fmt.Errorf("error loading the TLS certificate, err: tls: failed to find any PEM data in certificate input"),
+		},
+		{
+			"Should return an error when the client auth is not supported",
+			TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, ClientAuth: "optional"},
+			false,
+			fmt.Errorf(`TLS client auth "optional" is not supported`),
+		},
+		{
+			"Should return an error when the client CA file has no certificates",
+			TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: emptyFile, ClientAuth: "require"},
+			false,
+			fmt.Errorf("client CA file %s has no certificates", emptyFile),
+		},
+		{
+			"Should return the configuration",
+			TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, ClientAuth: "require"},
+			true,
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			tlsConfig, err := NewTLSConfig(c.config)
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+			assert.EqualValues(t, c.expectedConfig, tlsConfig != nil)
+		})
+	}
+}
+
+func TestCertificateReloader(t *testing.T) {
+	cases := []struct {
+		name                string
+		elapsed             time.Duration
+		newCertificateValid bool
+		expectedSerial      int64
+		expectedEventTypes  []string
+	}{
+		{
+			"Should keep the certificate until the interval elapses",
+			time.Second,
+			true,
+			1,
+			nil,
+		},
+		{
+			"Should reload the certificate when its files change",
+			time.Minute,
+			true,
+			2,
+			[]string{audit.EventConfigReloaded},
+		},
+		{
+			"Should keep the certificate when the new one is not valid",
+			time.Minute,
+			false,
+			1,
+			[]string{audit.EventConfigReloadFailed},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			dir := t.TempDir()
+			certFile, keyFile := testutil.WriteCertificate(t, dir, "server", 1)
+			reloader, err := newCertificateReloader(certFile, keyFile)
+			assert.Nil(t, err)
+			now := time.Now()
+			reloader.now = func() time.Time {
+				return now
+			}
+			reloader.interval = 10 * time.Second
+			auditLog := &bytes.Buffer{}
+			reloader.auditor = audit.NewAuditor(auditLog)
+			_, _ = reloader.GetCertificate(nil)
+
+			testutil.WriteCertificate(t, dir, "server", 2)
+			if !c.newCertificateValid {
+				assert.Nil(t, ioutil.WriteFile(keyFile, []byte("invalid"), 0600))
+			}
+			modTime := time.Now().Add(time.Hour)
+			assert.Nil(t, os.Chtimes(certFile, modTime, modTime))
+			assert.Nil(t, os.Chtimes(keyFile, modTime, modTime))
+			now = now.Add(c.elapsed)
+
+			// Operation
+			certificate, err := reloader.GetCertificate(nil)
+
+			// Validation
+			assert.Nil(t, err)
+			leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+			assert.Nil(t, err)
+			assert.EqualValues(t, c.expectedSerial, leaf.SerialNumber.Int64())
+			var eventTypes []string
+			decoder := json.NewDecoder(auditLog)
+			for decoder.More() {
+				event := audit.Event{}
+				assert.Nil(t, decoder.Decode(&event))
+				assert.EqualValues(t, "tls_certificate", event.Details["component"])
+				assert.EqualValues(t, certFile, event.Details["cert_file"])
+				eventTypes = append(eventTypes, event.Type)
+			}
+			assert.EqualValues(t, c.expectedEventTypes, eventTypes)
+		})
+	}
+}
+
+func TestServerShouldServeHTTP2OverHTTPSAndVerifyTheClientCertificates(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	serverCertFile, serverKeyFile := testutil.WriteCertificate(t, dir, "server", 1)
+	clientCertFile, clientKeyFile := testutil.WriteCertificate(t, dir, "client", 2)
+	tlsConfig, err := NewTLSConfig(TLSConfig{
+		CertFile:     serverCertFile,
+		KeyFile:      serverKeyFile,
+		ClientCAFile: clientCertFile,
+		ClientAuth:   "require",
+	})
+	assert.Nil(t, err)
+	server := NewServer(nil, handler.NewHealthHandler(nil), nil, WithTLS(tlsConfig))
+	assert.Nil(t, server.Start("127.0.0.1:0"))
+	defer func() {
+		assert.Nil(t, server.Shutdown(context.Background()))
+	}()
+
+	rootCAs, err := loadClientCAs(serverCertFile)
+	assert.Nil(t, err)
+	clientCertificate, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	assert.Nil(t, err)
+	newClient := func(certificates ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: rootCAs, Certificates: certificates},
+				ForceAttemptHTTP2: true,
+			},
+		}
+	}
+
+	// Operation
+	response, err := newClient(clientCertificate).Get(fmt.Sprintf("https://%s/healthz", server.Addr()))
+	_, errWithoutCertificate := newClient().Get(fmt.Sprintf("https://%s/healthz", server.Addr()))
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+	assert.EqualValues(t, "HTTP/2.0", response.Proto)
+	_ = response.Body.Close()
+	assert.NotNil(t, errWithoutCertificate)
+}
@@ -1,6 +1,14 @@
 package constants
 
 const (
-	FoaasProtocol = "https"
-	FoaasDomain   = "foaas.com"
+	FoaasBaseURL          = "https://foaas.com"
+	FoaasDefaultOperation = "asshole"
 )
+
+// FoaasOperations are the FOAAS operations that only need the name of the sender (/:operation/:from).
+var FoaasOperations = []string{
+	"asshole", "awesome", "bag", "because", "bucket", "bye", "cool", "diabetes", "everyone", "everything",
+	"family", "fascinating", "flying", "give", "horse", "life", "looking", "maybe", "me", "mornin", "no",
+	"pink", "programmer", "question", "ridiculous", "rtfm", "sake", "shit", "single", "thanks", "that",
+	"this", "too", "tucker", "what", "zayn", "zero",
+}
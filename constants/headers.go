@@ -1,3 +1,6 @@
 package constants
 
-const UserIDHeader = "UserId"
+const (
+	UserIDHeader    = "UserId"
+	RequestIDHeader = "X-Request-ID"
+)
@@ -0,0 +1,158 @@
+package render
+
+import (
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"strings"
+)
+
+const (
+	lineSpacing   = 1.25
+	subtitleScale = 0.6
+	minFontSize   = 8
+)
+
+var cardFont = mustParseFont(goregular.TTF)
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	parsed, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+type textBlock struct {
+	fontSize float64
+	lines    []string
+}
+
+func (t textBlock) height() float64 {
+	return float64(len(t.lines)) * t.fontSize * lineSpacing
+}
+
+// cardLayout places the message and its subtitle inside the card. Both image renderers share it,
+// so an SVG and a PNG of the same message wrap their lines at the same words.
+type cardLayout struct {
+	padding  float64
+	barWidth float64
+	message  textBlock
+	subtitle textBlock
+	top      float64
+}
+
+// newCardLayout wraps the texts with the Go font, shrinking it until everything fits in the card.
+func newCardLayout(response *model.Response, options ImageOptions) (*cardLayout, error) {
+	padding := float64(options.Width) / 15
+	maxWidth := float64(options.Width) - 2*padding
+	maxHeight := float64(options.Height) - 2*padding
+
+	layout := &cardLayout{
+		padding:  padding,
+		barWidth: padding / 4,
+	}
+	for fontSize := float64(options.Height) / 8; ; fontSize *= 0.9 {
+		message, err := wrapText(response.Message, fontSize, maxWidth)
+		if err != nil {
+			return nil, err
+		}
+		subtitle, err := wrapText(response.Subtitle, fontSize*subtitleScale, maxWidth)
+		if err != nil {
+			return nil, err
+		}
+
+		layout.message = textBlock{fontSize: fontSize, lines: message}
+		layout.subtitle = textBlock{fontSize: fontSize * subtitleScale, lines: subtitle}
+		if layout.height() <= maxHeight || fontSize*subtitleScale <= minFontSize {
+			break
+		}
+	}
+
+	layout.top = (float64(options.Height) - layout.height()) / 2
+	return layout, nil
+}
+
+func (c *cardLayout) height() float64 {
+	return c.message.height() + c.gap() + c.subtitle.height()
+}
+
+func (c *cardLayout) gap() float64 {
+	if len(c.subtitle.lines) == 0 {
+		return 0
+	}
+	return c.message.fontSize / 2
+}
+
+// baselines returns the vertical position of every line of the message and then of the subtitle.
+func (c *cardLayout) baselines() ([]float64, []float64) {
+	messageBaselines := make([]float64, len(c.message.lines))
+	for i := range c.message.lines {
+		messageBaselines[i] = c.top + float64(i)*c.message.fontSize*lineSpacing + c.message.fontSize
+	}
+
+	subtitleTop := c.top + c.message.height() + c.gap()
+	subtitleBaselines := make([]float64, len(c.subtitle.lines))
+	for i := range c.subtitle.lines {
+		subtitleBaselines[i] = subtitleTop + float64(i)*c.subtitle.fontSize*lineSpacing + c.subtitle.fontSize
+	}
+	return messageBaselines, subtitleBaselines
+}
+
+func newFace(fontSize float64) (font.Face, error) {
+	return opentype.NewFace(cardFont, &opentype.FaceOptions{
+		Size:    fontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// wrapText splits the text in lines no wider than maxWidth, breaking inside the words that don't
+// fit in a line by themselves.
+func wrapText(text string, fontSize, maxWidth float64) ([]string, error) {
+	face, err := newFace(fontSize)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	fits := func(s string) bool {
+		return float64(font.MeasureString(face, s))/64 <= maxWidth
+	}
+
+	lines := make([]string, 0)
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if fits(candidate) {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+			line = ""
+		}
+		if fits(word) {
+			line = word
+			continue
+		}
+
+		for _, r := range word {
+			if line != "" && !fits(line+string(r)) {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
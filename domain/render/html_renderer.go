@@ -0,0 +1,38 @@
+package render
+
+import (
+	"bytes"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("message").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>FOAAS - {{.Message}} {{.Subtitle}}</title>
+</head>
+<body>
+<h1>{{.Message}}</h1>
+<p><em>{{.Subtitle}}</em></p>
+</body>
+</html>
+`))
+
+type HTMLRenderer struct{}
+
+func NewHTMLRenderer() *HTMLRenderer {
+	return &HTMLRenderer{}
+}
+
+func (*HTMLRenderer) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (*HTMLRenderer) Render(response *model.Response) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := htmlTemplate.Execute(buffer, response); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
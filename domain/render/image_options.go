@@ -0,0 +1,100 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+)
+
+const (
+	defaultImageWidth  = 1200
+	defaultImageHeight = 630
+	defaultImageTheme  = "dark"
+	minImageSize       = 100
+	maxImageSize       = 2000
+)
+
+var ErrUnsupportedImageFormat = errors.New("image format is not supported")
+
+type Theme struct {
+	Background color.RGBA
+	Foreground color.RGBA
+	Accent     color.RGBA
+}
+
+var Themes = map[string]Theme{
+	"dark": {
+		Background: color.RGBA{R: 0x1e, G: 0x1e, B: 0x2e, A: 0xff},
+		Foreground: color.RGBA{R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff},
+		Accent:     color.RGBA{R: 0xff, G: 0x55, B: 0x55, A: 0xff},
+	},
+	"light": {
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Foreground: color.RGBA{R: 0x1e, G: 0x1e, B: 0x2e, A: 0xff},
+		Accent:     color.RGBA{R: 0xd7, G: 0x26, B: 0x3d, A: 0xff},
+	},
+	"solarized": {
+		Background: color.RGBA{R: 0x00, G: 0x2b, B: 0x36, A: 0xff},
+		Foreground: color.RGBA{R: 0xee, G: 0xe8, B: 0xd5, A: 0xff},
+		Accent:     color.RGBA{R: 0xb5, G: 0x89, B: 0x00, A: 0xff},
+	},
+}
+
+type ImageOptions struct {
+	Width  int
+	Height int
+	Theme  Theme
+}
+
+// ParseImageOptions builds the options of an image from the raw query parameters, where empty values
+// fall back to the defaults.
+func ParseImageOptions(width, height, theme string) (ImageOptions, error) {
+	options := ImageOptions{
+		Width:  defaultImageWidth,
+		Height: defaultImageHeight,
+		Theme:  Themes[defaultImageTheme],
+	}
+
+	var err error
+	if width != "" {
+		if options.Width, err = parseImageSize("width", width); err != nil {
+			return ImageOptions{}, err
+		}
+	}
+
+	if height != "" {
+		if options.Height, err = parseImageSize("height", height); err != nil {
+			return ImageOptions{}, err
+		}
+	}
+
+	if theme != "" {
+		selectedTheme, exists := Themes[theme]
+		if !exists {
+			return ImageOptions{}, fmt.Errorf("theme %q is not supported", theme)
+		}
+		options.Theme = selectedTheme
+	}
+
+	return options, nil
+}
+
+func parseImageSize(name, value string) (int, error) {
+	size, err := strconv.Atoi(value)
+	if err != nil || size < minImageSize || size > maxImageSize {
+		return 0, fmt.Errorf("%s must be a number between %d and %d", name, minImageSize, maxImageSize)
+	}
+	return size, nil
+}
+
+// NewImageRenderer returns the renderer for an image extension, either .svg or .png.
+func NewImageRenderer(extension string, options ImageOptions) (Renderer, error) {
+	switch extension {
+	case ".svg":
+		return NewSVGRenderer(options), nil
+	case ".png":
+		return NewPNGRenderer(options), nil
+	}
+	return nil, ErrUnsupportedImageFormat
+}
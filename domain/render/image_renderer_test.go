@@ -0,0 +1,205 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestParseImageOptions(t *testing.T) {
+	cases := []struct {
+		name            string
+		width           string
+		height          string
+		theme           string
+		expectedOptions ImageOptions
+		expectedError   error
+	}{
+		{
+			"Should return the default options when parameters are empty",
+			"",
+			"",
+			"",
+			ImageOptions{Width: 1200, Height: 630, Theme: Themes["dark"]},
+			nil,
+		},
+		{
+			"Should return the options from the parameters",
+			"400",
+			"300",
+			"light",
+			ImageOptions{Width: 400, Height: 300, Theme: Themes["light"]},
+			nil,
+		},
+		{
+			"Should return an error when width is not a number",
+			"wide",
+			"",
+			"",
+			ImageOptions{},
+			fmt.Errorf("width must be a number between 100 and 2000"),
+		},
+		{
+			"Should return an error when height is out of bounds",
+			"",
+			"5000",
+			"",
+			ImageOptions{},
+			fmt.Errorf("height must be a number between 100 and 2000"),
+		},
+		{
+			"Should return an error when theme is not supported",
+			"",
+			"",
+			"neon",
+			ImageOptions{},
+			fmt.Errorf(`theme "neon" is not supported`),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			options, err := ParseImageOptions(c.width, c.height, c.theme)
+
+			// Validation
+			assert.EqualValues(t, c.expectedOptions, options)
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
+
+func TestNewImageRenderer(t *testing.T) {
+	options := ImageOptions{Width: 400, Height: 300, Theme: Themes["dark"]}
+
+	cases := []struct {
+		name             string
+		extension        string
+		expectedRenderer Renderer
+		expectedError    error
+	}{
+		{
+			"Should return the svg renderer",
+			".svg",
+			NewSVGRenderer(options),
+			nil,
+		},
+		{
+			"Should return the png renderer",
+			".png",
+			NewPNGRenderer(options),
+			nil,
+		},
+		{
+			"Should return an error when extension is not supported",
+			".gif",
+			nil,
+			ErrUnsupportedImageFormat,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			renderer, err := NewImageRenderer(c.extension, options)
+
+			// Validation
+			assert.EqualValues(t, c.expectedRenderer, renderer)
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	cases := []struct {
+		name          string
+		text          string
+		maxWidth      float64
+		expectedLines []string
+	}{
+		{
+			"Should return no lines when text is empty",
+			"",
+			100,
+			[]string{},
+		},
+		{
+			"Should return a single line when text fits",
+			"Fuck you",
+			1000,
+			[]string{"Fuck you"},
+		},
+		{
+			"Should break the text between words",
+			"Fuck you asshole",
+			60,
+			[]string{"Fuck", "you", "asshole"},
+		},
+		{
+			"Should break the words that don't fit in a line",
+			"aaaaaaaaaa",
+			30,
+			[]string{"aaa", "aaa", "aaa", "a"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			lines, err := wrapText(c.text, 16, c.maxWidth)
+
+			// Validation
+			assert.Nil(t, err)
+			assert.EqualValues(t, c.expectedLines, lines)
+		})
+	}
+}
+
+func TestSVGRendererRender(t *testing.T) {
+	// Initialization
+	renderer := NewSVGRenderer(ImageOptions{Width: 600, Height: 300, Theme: Themes["light"]})
+
+	// Operation
+	body, err := renderer.Render(&model.Response{
+		Message:  "Fuck you, <script>asshole</script>.",
+		Subtitle: "- 123",
+	})
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, "image/svg+xml", renderer.ContentType())
+	assert.True(t, strings.HasPrefix(string(body),
+		`<svg xmlns="http://www.w3.org/2000/svg" width="600" height="300" viewBox="0 0 600 300">`))
+	assert.Contains(t, string(body), `<rect width="100%" height="100%" fill="#ffffff"/>`)
+	assert.Contains(t, string(body), "&lt;script&gt;asshole&lt;/script&gt;.")
+	assert.NotContains(t, string(body), "<script>")
+	assert.Contains(t, string(body), `fill="#d7263d">`+"\n"+`<tspan x="40.0" y="`)
+	assert.Contains(t, string(body), ">- 123</tspan>")
+}
+
+func TestPNGRendererRender(t *testing.T) {
+	// Initialization
+	options := ImageOptions{Width: 600, Height: 300, Theme: Themes["dark"]}
+	renderer := NewPNGRenderer(options)
+
+	// Operation
+	body, err := renderer.Render(&model.Response{
+		Message:  "Fuck you, asshole.",
+		Subtitle: "- 123",
+	})
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, "image/png", renderer.ContentType())
+
+	card, err := png.Decode(bytes.NewReader(body))
+	assert.Nil(t, err)
+	assert.EqualValues(t, 600, card.Bounds().Dx())
+	assert.EqualValues(t, 300, card.Bounds().Dy())
+	assert.EqualValues(t, options.Theme.Accent, card.At(0, 0))
+	assert.EqualValues(t, options.Theme.Background, card.At(599, 299))
+}
@@ -0,0 +1,20 @@
+package render
+
+import (
+	"encoding/json"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+)
+
+type JSONRenderer struct{}
+
+func NewJSONRenderer() *JSONRenderer {
+	return &JSONRenderer{}
+}
+
+func (*JSONRenderer) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (*JSONRenderer) Render(response *model.Response) ([]byte, error) {
+	return json.Marshal(response)
+}
@@ -0,0 +1,28 @@
+package render
+
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`, `[`, `\[`, `]`, `\]`,
+	`(`, `\(`, `)`, `\)`, `#`, `\#`, `+`, `\+`, `!`, `\!`, `<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+type MarkdownRenderer struct{}
+
+func NewMarkdownRenderer() *MarkdownRenderer {
+	return &MarkdownRenderer{}
+}
+
+func (*MarkdownRenderer) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (*MarkdownRenderer) Render(response *model.Response) ([]byte, error) {
+	return []byte(fmt.Sprintf("# %s\n\n_%s_\n",
+		markdownEscaper.Replace(response.Message),
+		markdownEscaper.Replace(response.Subtitle))), nil
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNotAcceptable = errors.New("none of the requested formats is supported")
+
+type offer struct {
+	format    string
+	mediaType string
+	renderer  Renderer
+}
+
+// offers is ordered by preference, the first one is used when the client accepts anything.
+var offers = []offer{
+	{"json", "application/json", NewJSONRenderer()},
+	{"text", "text/plain", NewTextRenderer()},
+	{"html", "text/html", NewHTMLRenderer()},
+	{"xml", "application/xml", NewXMLRenderer()},
+	{"markdown", "text/markdown", NewMarkdownRenderer()},
+}
+
+var formatAliases = map[string]string{
+	"txt": "text",
+	"htm": "html",
+	"md":  "markdown",
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// Negotiate picks the renderer for a request. A non-empty format (the ?format= query parameter) takes
+// precedence over the Accept header, and an empty Accept header means the client accepts anything.
+// Each offer gets the quality of the most specific media range that matches it, so a media range with
+// q=0 refuses the offers it matches even when a wildcard accepts them.
+func Negotiate(accept, format string) (Renderer, error) {
+	if format != "" {
+		return rendererForFormat(format)
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return offers[0].renderer, nil
+	}
+
+	mediaRanges := parseAccept(accept)
+	var renderer Renderer
+	bestRange := len(mediaRanges)
+	for _, o := range offers {
+		i := mostSpecificMediaRange(mediaRanges, o.mediaType)
+		if i < bestRange && mediaRanges[i].quality > 0 {
+			renderer = o.renderer
+			bestRange = i
+		}
+	}
+	if renderer == nil {
+		return nil, ErrNotAcceptable
+	}
+	return renderer, nil
+}
+
+func rendererForFormat(format string) (Renderer, error) {
+	format = strings.ToLower(format)
+	if alias, exists := formatAliases[format]; exists {
+		format = alias
+	}
+
+	for _, o := range offers {
+		if o.format == format {
+			return o.renderer, nil
+		}
+	}
+	return nil, ErrNotAcceptable
+}
+
+// parseAccept returns the media ranges of an Accept header sorted by quality and, on equal quality, by
+// specificity, keeping the ones the client refuses with q=0.
+func parseAccept(accept string) []mediaRange {
+	mediaRanges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if value, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				quality = value
+			}
+		}
+
+		mediaRanges = append(mediaRanges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(mediaRanges, func(i, j int) bool {
+		if mediaRanges[i].quality != mediaRanges[j].quality {
+			return mediaRanges[i].quality > mediaRanges[j].quality
+		}
+		return specificity(mediaRanges[i].mediaType) > specificity(mediaRanges[j].mediaType)
+	})
+	return mediaRanges
+}
+
+// mostSpecificMediaRange returns the index of the most specific media range that matches the offered
+// media type, or the number of media ranges when none matches.
+func mostSpecificMediaRange(mediaRanges []mediaRange, offered string) int {
+	index := len(mediaRanges)
+	for i, mediaRange := range mediaRanges {
+		if !matchesMediaRange(mediaRange.mediaType, offered) {
+			continue
+		}
+		if index == len(mediaRanges) ||
+			specificity(mediaRange.mediaType) > specificity(mediaRanges[index].mediaType) {
+			index = i
+		}
+	}
+	return index
+}
+
+// specificity ranks type/subtype over type/* over */*.
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func matchesMediaRange(accepted, offered string) bool {
+	if accepted == "*/*" || accepted == offered {
+		return true
+	}
+	if strings.HasSuffix(accepted, "/*") {
+		return strings.HasPrefix(offered, strings.TrimSuffix(accepted, "*"))
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		name             string
+		accept           string
+		format           string
+		expectedRenderer Renderer
+		expectedError    error
+	}{
+		{
+			"Should return json when accept header is empty",
+			"",
+			"",
+			NewJSONRenderer(),
+			nil,
+		},
+		{
+			"Should return json when accept header accepts anything",
+			"*/*",
+			"",
+			NewJSONRenderer(),
+			nil,
+		},
+		{
+			"Should return the first offered text format when accept header has a wildcard subtype",
+			"text/*",
+			"",
+			NewTextRenderer(),
+			nil,
+		},
+		{
+			"Should return the media type with the highest quality",
+			"text/plain;q=0.5, application/xml;q=0.9, text/html;q=0.7",
+			"",
+			NewXMLRenderer(),
+			nil,
+		},
+		{
+			"Should skip media types that are not supported",
+			"image/gif, text/markdown;q=0.1",
+			"",
+			NewMarkdownRenderer(),
+			nil,
+		},
+		{
+			"Should skip media types refused with a zero quality",
+			"text/html;q=0, */*;q=0.1",
+			"",
+			NewJSONRenderer(),
+			nil,
+		},
+		{
+			"Should not return a media type refused with a zero quality when a wildcard accepts anything",
+			"*/*, application/json;q=0",
+			"",
+			NewTextRenderer(),
+			nil,
+		},
+		{
+			"Should return an error when the wildcards refuse every media type",
+			"text/*;q=0, application/*;q=0, */*",
+			"",
+			nil,
+			ErrNotAcceptable,
+		},
+		{
+			"Should return the most specific media type on equal quality",
+			"*/*, text/*, text/html",
+			"",
+			NewHTMLRenderer(),
+			nil,
+		},
+		{
+			"Should return the media type that is more specific than the wildcard that refuses it",
+			"text/*;q=0, text/markdown;q=0.5",
+			"",
+			NewMarkdownRenderer(),
+			nil,
+		},
+		{
+			"Should return an error when no media type is supported",
+			"image/gif, text/html;q=0",
+			"",
+			nil,
+			ErrNotAcceptable,
+		},
+		{
+			"Should use the format over the accept header",
+			"application/json",
+			"HTML",
+			NewHTMLRenderer(),
+			nil,
+		},
+		{
+			"Should resolve format aliases",
+			"",
+			"md",
+			NewMarkdownRenderer(),
+			nil,
+		},
+		{
+			"Should return an error when the format is not supported",
+			"",
+			"yaml",
+			nil,
+			ErrNotAcceptable,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			renderer, err := Negotiate(c.accept, c.format)
+
+			// Validation
+			assert.EqualValues(t, c.expectedRenderer, renderer)
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"bytes"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+type PNGRenderer struct {
+	options ImageOptions
+}
+
+func NewPNGRenderer(options ImageOptions) *PNGRenderer {
+	return &PNGRenderer{
+		options: options,
+	}
+}
+
+func (*PNGRenderer) ContentType() string {
+	return "image/png"
+}
+
+func (p *PNGRenderer) Render(response *model.Response) ([]byte, error) {
+	layout, err := newCardLayout(response, p.options)
+	if err != nil {
+		return nil, err
+	}
+	messageBaselines, subtitleBaselines := layout.baselines()
+
+	card := image.NewRGBA(image.Rect(0, 0, p.options.Width, p.options.Height))
+	draw.Draw(card, card.Bounds(), image.NewUniform(p.options.Theme.Background), image.Point{}, draw.Src)
+	draw.Draw(card, image.Rect(0, 0, int(layout.barWidth), p.options.Height),
+		image.NewUniform(p.options.Theme.Accent), image.Point{}, draw.Src)
+
+	err = p.drawText(card, layout.message, messageBaselines, layout.padding, p.options.Theme.Foreground)
+	if err != nil {
+		return nil, err
+	}
+	err = p.drawText(card, layout.subtitle, subtitleBaselines, layout.padding, p.options.Theme.Accent)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := png.Encode(buffer, card); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (p *PNGRenderer) drawText(card draw.Image, block textBlock, baselines []float64, x float64,
+	fill color.RGBA) error {
+	face, err := newFace(block.fontSize)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{
+		Dst:  card,
+		Src:  image.NewUniform(fill),
+		Face: face,
+	}
+	for i, line := range block.lines {
+		drawer.Dot = fixed.P(int(x), int(baselines[i]))
+		drawer.DrawString(line)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package render
+
+import (
+	"github.com/hortelanobruno/foaas-api/domain/model"
+)
+
+type Renderer interface {
+	ContentType() string
+	Render(response *model.Response) ([]byte, error)
+}
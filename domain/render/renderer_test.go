@@ -0,0 +1,67 @@
+package render
+
+import (
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	response := &model.Response{
+		Message:  "Fuck you, <b>asshole</b>.",
+		Subtitle: "- 123",
+	}
+
+	cases := []struct {
+		name                string
+		renderer            Renderer
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			"Should render json escaping the message",
+			NewJSONRenderer(),
+			"application/json; charset=utf-8",
+			`{"message":"Fuck you, \u003cb\u003easshole\u003c/b\u003e.","subtitle":"- 123"}`,
+		},
+		{
+			"Should render text",
+			NewTextRenderer(),
+			"text/plain; charset=utf-8",
+			"Fuck you, <b>asshole</b>. - 123",
+		},
+		{
+			"Should render html escaping the message",
+			NewHTMLRenderer(),
+			"text/html; charset=utf-8",
+			"<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n" +
+				"<title>FOAAS - Fuck you, &lt;b&gt;asshole&lt;/b&gt;. - 123</title>\n</head>\n<body>\n" +
+				"<h1>Fuck you, &lt;b&gt;asshole&lt;/b&gt;.</h1>\n<p><em>- 123</em></p>\n</body>\n</html>\n",
+		},
+		{
+			"Should render xml escaping the message",
+			NewXMLRenderer(),
+			"application/xml; charset=utf-8",
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+				"<response><message>Fuck you, &lt;b&gt;asshole&lt;/b&gt;.</message><subtitle>- 123</subtitle></response>",
+		},
+		{
+			"Should render markdown escaping the message",
+			NewMarkdownRenderer(),
+			"text/markdown; charset=utf-8",
+			"# Fuck you, \\<b\\>asshole\\</b\\>.\n\n_- 123_\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			body, err := c.renderer.Render(response)
+
+			// Validation
+			assert.Nil(t, err)
+			assert.EqualValues(t, c.expectedContentType, c.renderer.ContentType())
+			assert.EqualValues(t, c.expectedBody, string(body))
+		})
+	}
+}
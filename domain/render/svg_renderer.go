@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"image/color"
+)
+
+const svgFontFamily = "Go, Helvetica, Arial, sans-serif"
+
+type SVGRenderer struct {
+	options ImageOptions
+}
+
+func NewSVGRenderer(options ImageOptions) *SVGRenderer {
+	return &SVGRenderer{
+		options: options,
+	}
+}
+
+func (*SVGRenderer) ContentType() string {
+	return "image/svg+xml"
+}
+
+func (s *SVGRenderer) Render(response *model.Response) ([]byte, error) {
+	layout, err := newCardLayout(response, s.options)
+	if err != nil {
+		return nil, err
+	}
+	messageBaselines, subtitleBaselines := layout.baselines()
+
+	buffer := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(buffer,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		s.options.Width, s.options.Height, s.options.Width, s.options.Height)
+	_, _ = fmt.Fprintf(buffer, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n",
+		hexColor(s.options.Theme.Background))
+	_, _ = fmt.Fprintf(buffer, `<rect width="%.0f" height="100%%" fill="%s"/>`+"\n", layout.barWidth,
+		hexColor(s.options.Theme.Accent))
+	err = s.writeText(buffer, layout.message, messageBaselines, layout.padding, s.options.Theme.Foreground)
+	if err != nil {
+		return nil, err
+	}
+	err = s.writeText(buffer, layout.subtitle, subtitleBaselines, layout.padding, s.options.Theme.Accent)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString("</svg>\n")
+
+	return buffer.Bytes(), nil
+}
+
+func (s *SVGRenderer) writeText(buffer *bytes.Buffer, block textBlock, baselines []float64, x float64,
+	fill color.RGBA) error {
+	if len(block.lines) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(buffer, `<text font-family="%s" font-size="%.1f" fill="%s">`+"\n", svgFontFamily,
+		block.fontSize, hexColor(fill))
+	for i, line := range block.lines {
+		_, _ = fmt.Fprintf(buffer, `<tspan x="%.1f" y="%.1f">`, x, baselines[i])
+		if err := xml.EscapeText(buffer, []byte(line)); err != nil {
+			return err
+		}
+		buffer.WriteString("</tspan>\n")
+	}
+	buffer.WriteString("</text>\n")
+	return nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
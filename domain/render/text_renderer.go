@@ -0,0 +1,20 @@
+package render
+
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+)
+
+type TextRenderer struct{}
+
+func NewTextRenderer() *TextRenderer {
+	return &TextRenderer{}
+}
+
+func (*TextRenderer) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (*TextRenderer) Render(response *model.Response) ([]byte, error) {
+	return []byte(fmt.Sprintf("%s %s", response.Message, response.Subtitle)), nil
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"encoding/xml"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+)
+
+type xmlResponse struct {
+	XMLName  xml.Name `xml:"response"`
+	Message  string   `xml:"message"`
+	Subtitle string   `xml:"subtitle"`
+}
+
+type XMLRenderer struct{}
+
+func NewXMLRenderer() *XMLRenderer {
+	return &XMLRenderer{}
+}
+
+func (*XMLRenderer) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (*XMLRenderer) Render(response *model.Response) ([]byte, error) {
+	body, err := xml.Marshal(xmlResponse{
+		Message:  response.Message,
+		Subtitle: response.Subtitle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/hortelanobruno/foaas-api/cache"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"sync/atomic"
+)
+
+type CacheStats struct {
+	cache.Stats
+	StaleHits uint64
+}
+
+// CachedMessageService decorates a MessageService, answering from the cache while the messages are
+// fresh and falling back to expired ones when the decorated service fails, unless the caller is gone.
+type CachedMessageService struct {
+	messageService MessageService
+	cache          cache.Cache
+	staleHits      uint64
+}
+
+func NewCachedMessageService(messageService MessageService, cache cache.Cache) *CachedMessageService {
+	return &CachedMessageService{
+		messageService: messageService,
+		cache:          cache,
+	}
+}
+
+func (c *CachedMessageService) GetMessage(ctx context.Context, operation, userID string) (*model.Response, error) {
+	key := fmt.Sprintf("%s/%s", operation, userID)
+	value, fresh, found := c.cache.Get(key)
+	if fresh {
+		return value.(*model.Response), nil
+	}
+
+	response, err := c.messageService.GetMessage(ctx, operation, userID)
+	if err != nil {
+		// Nobody is waiting for the stale message of a canceled call.
+		if found && ctx.Err() == nil && apierror.KindOf(err) != apierror.KindCanceled {
+			atomic.AddUint64(&c.staleHits, 1)
+			logging.FromContext(ctx).Warnf("Serving stale message for key: %s, err: %s", key, err.Error())
+			return value.(*model.Response), nil
+		}
+		return nil, err
+	}
+
+	c.cache.Set(key, response)
+	return response, nil
+}
+
+func (c *CachedMessageService) Stats() CacheStats {
+	return CacheStats{
+		Stats:     c.cache.Stats(),
+		StaleHits: atomic.LoadUint64(&c.staleHits),
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/hortelanobruno/foaas-api/cache"
+	cachemock "github.com/hortelanobruno/foaas-api/cache/mocks"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	servicemock "github.com/hortelanobruno/foaas-api/domain/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestCachedGetMessage(t *testing.T) {
+	cachedResponse := &model.Response{Message: "Fuck you, asshole.", Subtitle: "- cached"}
+	upstreamResponse := &model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"}
+
+	cases := []struct {
+		name               string
+		mockCache          *cachemock.Cache
+		mockMessageService *servicemock.MessageService
+		expectedResponse   *model.Response
+		expectedError      error
+		expectedStaleHits  uint64
+	}{
+		{
+			"Should return the cached message when it is fresh",
+			func() *cachemock.Cache {
+				mock := &cachemock.Cache{}
+				mock.On("Get", "asshole/123").Return(cachedResponse, true, true)
+				mock.On("Stats").Return(cache.Stats{})
+				return mock
+			}(),
+			&servicemock.MessageService{},
+			cachedResponse,
+			nil,
+			0,
+		},
+		{
+			"Should store the message when it is not cached",
+			func() *cachemock.Cache {
+				mock := &cachemock.Cache{}
+				mock.On("Get", "asshole/123").Return(nil, false, false)
+				mock.On("Set", "asshole/123", upstreamResponse).Return()
+				mock.On("Stats").Return(cache.Stats{})
+				return mock
+			}(),
+			func() *servicemock.MessageService {
+				mock := &servicemock.MessageService{}
+				mock.On("GetMessage", context.Background(), "asshole", "123").Return(upstreamResponse, nil)
+				return mock
+			}(),
+			upstreamResponse,
+			nil,
+			0,
+		},
+		{
+			"Should refresh the message when it is stale",
+			func() *cachemock.Cache {
+				mock := &cachemock.Cache{}
+				mock.On("Get", "asshole/123").Return(cachedResponse, false, true)
+				mock.On("Set", "asshole/123", upstreamResponse).Return()
+				mock.On("Stats").Return(cache.Stats{})
+				return mock
+			}(),
+			func() *servicemock.MessageService {
+				mock := &servicemock.MessageService{}
+				mock.On("GetMessage", context.Background(), "asshole", "123").Return(upstreamResponse, nil)
+				return mock
+			}(),
+			upstreamResponse,
+			nil,
+			0,
+		},
+		{
+			"Should return the stale message when the service returns an error",
+			func() *cachemock.Cache {
+				mock := &cachemock.Cache{}
+				mock.On("Get", "asshole/123").Return(cachedResponse, false, true)
+				mock.On("Stats").Return(cache.Stats{})
+				return mock
+			}(),
+			func() *servicemock.MessageService {
+				mock := &servicemock.MessageService{}
+				mock.On("GetMessage", context.Background(), "asshole", "123").Return(nil, fmt.Errorf("error getting message"))
+				return mock
+			}(),
+			cachedResponse,
+			nil,
+			1,
+		},
+		{
+			"Should return an error instead of the stale message when the call is canceled",
+			func() *cachemock.Cache {
+				mock := &cachemock.Cache{}
+				mock.On("Get", "asshole/123").Return(cachedResponse, false, true)
+				mock.On("Stats").Return(cache.Stats{})
+				return mock
+			}(),
+			func() *servicemock.MessageService {
+				mock := &servicemock.MessageService{}
+				mock.On("GetMessage", context.Background(), "asshole", "123").
+					Return(nil, apierror.New(apierror.KindCanceled, context.Canceled))
+				return mock
+			}(),
+			nil,
+			apierror.New(apierror.KindCanceled, context.Canceled),
+			0,
+		},
+		{
+			"Should return an error when the service returns an error and the message is not cached",
+			func() *cachemock.Cache {
+				mock := &cachemock.Cache{}
+				mock.On("Get", "asshole/123").Return(nil, false, false)
+				mock.On("Stats").Return(cache.Stats{})
+				return mock
+			}(),
+			func() *servicemock.MessageService {
+				mock := &servicemock.MessageService{}
+				mock.On("GetMessage", context.Background(), "asshole", "123").Return(nil, fmt.Errorf("error getting message"))
+				return mock
+			}(),
+			nil,
+			fmt.Errorf("error getting message"),
+			0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			service := NewCachedMessageService(c.mockMessageService, c.mockCache)
+
+			// Operation
+			response, err := service.GetMessage(context.Background(), "asshole", "123")
+
+			// Validation
+			assert.EqualValues(t, c.expectedResponse, response)
+			assert.EqualValues(t, c.expectedError, err)
+			assert.EqualValues(t, c.expectedStaleHits, service.Stats().StaleHits)
+			c.mockCache.AssertExpectations(t)
+			c.mockMessageService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCachedGetMessageShouldNotReturnTheStaleMessageWhenTheCallerIsGone(t *testing.T) {
+	// Initialization
+	ctx, cancel := context.WithCancel(context.Background())
+	mockCache := &cachemock.Cache{}
+	mockCache.On("Get", "asshole/123").Return(&model.Response{Message: "Fuck you, asshole."}, false, true)
+	mockCache.On("Stats").Return(cache.Stats{})
+	mockMessageService := &servicemock.MessageService{}
+	mockMessageService.On("GetMessage", ctx, "asshole", "123").Run(func(mock.Arguments) { cancel() }).
+		Return(nil, fmt.Errorf("error getting message"))
+	service := NewCachedMessageService(mockMessageService, mockCache)
+
+	// Operation
+	response, err := service.GetMessage(ctx, "asshole", "123")
+
+	// Validation
+	assert.Nil(t, response)
+	assert.EqualValues(t, fmt.Errorf("error getting message"), err)
+	assert.EqualValues(t, 0, service.Stats().StaleHits)
+}
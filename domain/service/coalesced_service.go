@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+type call struct {
+	done     chan struct{}
+	response *model.Response
+	err      error
+	waiters  int
+	cancel   context.CancelFunc
+	span     trace.Span
+}
+
+// CoalescedMessageService decorates a MessageService so concurrent calls for the same operation and user
+// share a single call to the decorated service, and its response or error. The shared call is canceled
+// once all the callers waiting for it are gone.
+type CoalescedMessageService struct {
+	messageService MessageService
+	calls          map[string]*call
+	mutex          *sync.Mutex
+}
+
+func NewCoalescedMessageService(messageService MessageService) *CoalescedMessageService {
+	return &CoalescedMessageService{
+		messageService: messageService,
+		calls:          make(map[string]*call),
+		mutex:          &sync.Mutex{},
+	}
+}
+
+func (c *CoalescedMessageService) GetMessage(ctx context.Context, operation, userID string) (*model.Response, error) {
+	key := fmt.Sprintf("%s/%s", operation, userID)
+
+	c.mutex.Lock()
+	sharedCall, exists := c.calls[key]
+	if !exists {
+		sharedCtx, cancel := context.WithCancel(detachedContext{parent: ctx})
+		// The shared call has its own span, since it can outlive the span of the caller that started it.
+		sharedCtx, span := tracer.Start(sharedCtx, "CoalescedMessageService.sharedCall", trace.WithNewRoot(),
+			trace.WithLinks(trace.LinkFromContext(ctx)),
+			trace.WithAttributes(attribute.String("foaas.operation", operation)))
+		sharedCall = &call{
+			done:   make(chan struct{}),
+			cancel: cancel,
+			span:   span,
+		}
+		c.calls[key] = sharedCall
+		go c.execute(sharedCtx, key, sharedCall, operation, userID)
+	}
+	sharedCall.waiters++
+	c.mutex.Unlock()
+
+	ctx, span := tracer.Start(ctx, "CoalescedMessageService.GetMessage",
+		trace.WithLinks(trace.Link{SpanContext: sharedCall.span.SpanContext()}),
+		trace.WithAttributes(attribute.Bool("foaas.coalesced", exists)))
+	defer span.End()
+
+	select {
+	case <-sharedCall.done:
+		return sharedCall.response, sharedCall.err
+	case <-ctx.Done():
+		c.mutex.Lock()
+		sharedCall.waiters--
+		if sharedCall.waiters == 0 {
+			// Later callers must start a new call instead of joining the canceled one.
+			if c.calls[key] == sharedCall {
+				delete(c.calls, key)
+			}
+			sharedCall.cancel()
+		}
+		c.mutex.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// execute runs the shared call, where a panic of the decorated service becomes the error of the call, since
+// it happens outside the goroutines of the callers.
+func (c *CoalescedMessageService) execute(ctx context.Context, key string, sharedCall *call,
+	operation, userID string) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logging.FromContext(ctx).Errorf("Panicking in the shared call for key: %s, panic: %v\n%s", key,
+				recovered, debug.Stack())
+			sharedCall.response, sharedCall.err = nil, fmt.Errorf("error getting the message, panic: %v", recovered)
+		}
+		if sharedCall.err != nil {
+			recordError(sharedCall.span, sharedCall.err)
+		}
+		sharedCall.span.End()
+		sharedCall.cancel()
+
+		c.mutex.Lock()
+		if c.calls[key] == sharedCall {
+			delete(c.calls, key)
+		}
+		c.mutex.Unlock()
+		close(sharedCall.done)
+	}()
+	sharedCall.response, sharedCall.err = c.messageService.GetMessage(ctx, operation, userID)
+}
+
+// detachedContext keeps the values of the context of the first caller, but not its deadline nor its
+// cancellation, because the call is shared with the rest of the callers.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	servicemock "github.com/hortelanobruno/foaas-api/domain/service/mocks"
+	"github.com/hortelanobruno/foaas-api/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+	"testing"
+	"time"
+)
+
+const concurrentCallers = 50
+
+func TestCoalescedGetMessageShouldCallTheServiceOnceForConcurrentIdenticalCalls(t *testing.T) {
+	cases := []struct {
+		name             string
+		upstreamResponse *model.Response
+		upstreamError    error
+	}{
+		{
+			"Should share the response",
+			&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"},
+			nil,
+		},
+		{
+			"Should share the error",
+			nil,
+			fmt.Errorf("error getting message"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			release := make(chan time.Time)
+			mockMessageService := &servicemock.MessageService{}
+			mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+				WaitUntil(release).
+				Return(c.upstreamResponse, c.upstreamError)
+			service := NewCoalescedMessageService(mockMessageService)
+
+			responses := make([]*model.Response, concurrentCallers)
+			errs := make([]error, concurrentCallers)
+			finished := &sync.WaitGroup{}
+			finished.Add(concurrentCallers)
+
+			// Operation
+			for i := 0; i < concurrentCallers; i++ {
+				go func(i int) {
+					defer finished.Done()
+					responses[i], errs[i] = service.GetMessage(context.Background(), "asshole", "123")
+				}(i)
+			}
+			waitForWaiters(t, service, "asshole/123", concurrentCallers)
+			close(release)
+			finished.Wait()
+
+			// Validation
+			mockMessageService.AssertNumberOfCalls(t, "GetMessage", 1)
+			for i := 0; i < concurrentCallers; i++ {
+				assert.EqualValues(t, c.upstreamResponse, responses[i])
+				assert.EqualValues(t, c.upstreamError, errs[i])
+			}
+		})
+	}
+}
+
+func TestCoalescedGetMessageShouldNotShareCallsForDifferentUsers(t *testing.T) {
+	// Initialization
+	mockMessageService := &servicemock.MessageService{}
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+		Return(&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"}, nil)
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "456").
+		Return(&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 456"}, nil)
+	service := NewCoalescedMessageService(mockMessageService)
+
+	// Operation
+	response123, err123 := service.GetMessage(context.Background(), "asshole", "123")
+	response456, err456 := service.GetMessage(context.Background(), "asshole", "456")
+
+	// Validation
+	assert.Nil(t, err123)
+	assert.Nil(t, err456)
+	assert.EqualValues(t, "- 123", response123.Subtitle)
+	assert.EqualValues(t, "- 456", response456.Subtitle)
+	mockMessageService.AssertNumberOfCalls(t, "GetMessage", 2)
+}
+
+func TestCoalescedGetMessageShouldKeepTheSharedCallWhenACallerCancels(t *testing.T) {
+	// Initialization
+	release := make(chan struct{})
+	upstreamCtx := make(chan context.Context, 1)
+	mockMessageService := &servicemock.MessageService{}
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+		Run(func(args mock.Arguments) {
+			upstreamCtx <- args.Get(0).(context.Context)
+			<-release
+		}).
+		Return(&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"}, nil)
+	service := NewCoalescedMessageService(mockMessageService)
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	canceledErr := make(chan error, 1)
+	go func() {
+		_, err := service.GetMessage(canceledCtx, "asshole", "123")
+		canceledErr <- err
+	}()
+	waitForWaiters(t, service, "asshole/123", 1)
+
+	// Operation
+	response := make(chan *model.Response, 1)
+	go func() {
+		r, _ := service.GetMessage(context.Background(), "asshole", "123")
+		response <- r
+	}()
+	waitForWaiters(t, service, "asshole/123", 2)
+	cancel()
+
+	// Validation
+	assert.EqualValues(t, context.Canceled, <-canceledErr)
+	assert.Nil(t, (<-upstreamCtx).Err())
+	close(release)
+	assert.EqualValues(t, "- 123", (<-response).Subtitle)
+	mockMessageService.AssertNumberOfCalls(t, "GetMessage", 1)
+}
+
+func TestCoalescedGetMessageShouldCancelTheSharedCallWhenAllCallersCancel(t *testing.T) {
+	// Initialization
+	upstreamCtx := make(chan context.Context, 1)
+	mockMessageService := &servicemock.MessageService{}
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			upstreamCtx <- ctx
+			<-ctx.Done()
+		}).
+		Return(nil, context.Canceled)
+	service := NewCoalescedMessageService(mockMessageService)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Operation
+	errs := make(chan error, 1)
+	go func() {
+		_, err := service.GetMessage(ctx, "asshole", "123")
+		errs <- err
+	}()
+	sharedCtx := <-upstreamCtx
+	cancel()
+
+	// Validation
+	assert.EqualValues(t, context.Canceled, <-errs)
+	<-sharedCtx.Done()
+	assert.EqualValues(t, context.Canceled, sharedCtx.Err())
+}
+
+func TestCoalescedGetMessageShouldReturnAnErrorWhenTheServicePanics(t *testing.T) {
+	// Initialization
+	mockMessageService := &servicemock.MessageService{}
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+		Run(func(args mock.Arguments) {
+			panic("unexpected nil response")
+		}).
+		Return(nil, nil).Once()
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+		Return(&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"}, nil).Once()
+	service := NewCoalescedMessageService(mockMessageService)
+
+	// Operation
+	panicResponse, panicErr := service.GetMessage(context.Background(), "asshole", "123")
+	response, err := service.GetMessage(context.Background(), "asshole", "123")
+
+	// Validation
+	assert.Nil(t, panicResponse)
+	assert.EqualValues(t, fmt.Errorf("error getting the message, panic: unexpected nil response"), panicErr)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "- 123", response.Subtitle)
+}
+
+func TestCoalescedGetMessageShouldTraceTheSharedCallInItsOwnSpanLinkedToTheCallers(t *testing.T) {
+	// Initialization
+	exporter := testutil.RecordSpans()
+	release := make(chan time.Time)
+	mockMessageService := &servicemock.MessageService{}
+	mockMessageService.On("GetMessage", mock.Anything, "asshole", "123").
+		WaitUntil(release).
+		Return(&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"}, nil)
+	service := NewCoalescedMessageService(mockMessageService)
+
+	callerSpans := make([]trace.SpanContext, 2)
+	finished := &sync.WaitGroup{}
+	finished.Add(len(callerSpans))
+
+	// Operation
+	for i := range callerSpans {
+		ctx, span := otel.Tracer("test").Start(context.Background(), "caller")
+		callerSpans[i] = span.SpanContext()
+		go func() {
+			defer finished.Done()
+			_, _ = service.GetMessage(ctx, "asshole", "123")
+			span.End()
+		}()
+		waitForWaiters(t, service, "asshole/123", i+1)
+	}
+	close(release)
+	finished.Wait()
+
+	// Validation
+	spans := map[string][]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+	assert.Len(t, spans["CoalescedMessageService.sharedCall"], 1)
+	sharedCall := spans["CoalescedMessageService.sharedCall"][0]
+	assert.False(t, sharedCall.Parent.IsValid())
+	assert.EqualValues(t, []sdktrace.Link{{SpanContext: callerSpans[0]}}, sharedCall.Links)
+	assert.Len(t, spans["CoalescedMessageService.GetMessage"], 2)
+	for _, span := range spans["CoalescedMessageService.GetMessage"] {
+		assert.Contains(t, callerSpans, span.Parent)
+		assert.EqualValues(t, []sdktrace.Link{{SpanContext: sharedCall.SpanContext}}, span.Links)
+	}
+}
+
+func waitForWaiters(t *testing.T, service *CoalescedMessageService, key string, waiters int) {
+	for i := 0; i < 1000; i++ {
+		service.mutex.Lock()
+		sharedCall, exists := service.calls[key]
+		current := 0
+		if exists {
+			current = sharedCall.waiters
+		}
+		service.mutex.Unlock()
+
+		if current == waiters {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %d callers of %s", waiters, key)
+}
@@ -2,13 +2,23 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/apierror"
 	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/domain/render"
 	"github.com/hortelanobruno/foaas-api/domain/service"
 	"github.com/hortelanobruno/foaas-api/domain/validator"
-	"github.com/sirupsen/logrus"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"path"
+	"strings"
 )
 
+var tracer = otel.Tracer("github.com/hortelanobruno/foaas-api/domain/service/handler")
+
 type MessageHandler struct {
 	messageValidator validator.MessageValidator
 	messageService   service.MessageService
@@ -23,24 +33,97 @@ func NewMessageHandler(messageValidator validator.MessageValidator,
 }
 
 func (m *MessageHandler) HandleGetMessage(ginContext *gin.Context) {
+	logger := logging.FromContext(ginContext.Request.Context())
 	userID := ginContext.GetHeader(constants.UserIDHeader)
 	if err := m.messageValidator.ValidateMessage(userID); err != nil {
-		logrus.Errorf("Error validating the message, userID: %s", userID)
-		ginContext.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		logger.Errorf("Error validating the message, err: %s", err.Error())
+		apierror.WriteProblem(ginContext, apierror.New(apierror.KindValidation, err))
+		return
+	}
+
+	renderer, err := render.Negotiate(ginContext.GetHeader("Accept"), ginContext.Query("format"))
+	if err != nil {
+		logger.Errorf("Error negotiating the format, err: %s", err.Error())
+		apierror.WriteProblem(ginContext, apierror.New(apierror.KindNotAcceptable, err))
+		return
+	}
+
+	m.renderMessage(ginContext, renderer, constants.FoaasDefaultOperation, userID)
+}
+
+// HandleGetMessageImage serves /message/:operation, where the operation carries the extension of
+// the image to render, like asshole.svg or asshole.png.
+func (m *MessageHandler) HandleGetMessageImage(ginContext *gin.Context) {
+	logger := logging.FromContext(ginContext.Request.Context())
+	userID := ginContext.GetHeader(constants.UserIDHeader)
+	if err := m.messageValidator.ValidateMessage(userID); err != nil {
+		logger.Errorf("Error validating the message, err: %s", err.Error())
+		apierror.WriteProblem(ginContext, apierror.New(apierror.KindValidation, err))
+		return
+	}
+
+	extension := path.Ext(ginContext.Param("operation"))
+	operation := strings.TrimSuffix(ginContext.Param("operation"), extension)
+	if err := m.messageValidator.ValidateOperation(operation); err != nil {
+		logger.Errorf("Error validating the operation: %s, err: %s", operation, err.Error())
+		apierror.WriteProblem(ginContext, apierror.New(apierror.KindValidation, err))
+		return
+	}
+
+	options, err := render.ParseImageOptions(ginContext.Query("width"), ginContext.Query("height"),
+		ginContext.Query("theme"))
+	if err != nil {
+		logger.Errorf("Error parsing the image options, err: %s", err.Error())
+		apierror.WriteProblem(ginContext, apierror.New(apierror.KindValidation, err))
 		return
 	}
 
-	response, err := m.messageService.GetMessage(userID)
+	renderer, err := render.NewImageRenderer(extension, options)
 	if err != nil {
-		logrus.Errorf("Error getting the message, userID: %s, err: %s", userID, err.Error())
-		ginContext.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		logger.Errorf("Error getting the image renderer, extension: %s", extension)
+		apierror.WriteProblem(ginContext, apierror.New(apierror.KindNotFound, err))
 		return
 	}
 
-	ginContext.JSON(http.StatusOK, response)
-	return
+	m.renderMessage(ginContext, renderer, operation, userID)
+}
+
+func (m *MessageHandler) renderMessage(ginContext *gin.Context, renderer render.Renderer, operation, userID string) {
+	ctx, span := tracer.Start(ginContext.Request.Context(), "MessageHandler.renderMessage",
+		trace.WithAttributes(attribute.String("foaas.operation", operation),
+			attribute.String("render.content_type", renderer.ContentType())))
+	defer span.End()
+
+	logger := logging.FromContext(ctx)
+	response, err := m.messageService.GetMessage(ctx, operation, userID)
+	if err != nil && apierror.KindOf(err) == apierror.KindCanceled {
+		// The client disconnected, which is not an error of the server.
+		logger.Infof("Canceling the message, the client closed the request")
+		span.AddEvent("canceled")
+		apierror.WriteProblem(ginContext, err)
+		return
+	}
+	if err != nil {
+		logger.Errorf("Error getting the message, err: %s", err.Error())
+		recordError(span, err)
+		apierror.WriteProblem(ginContext, err)
+		return
+	}
+
+	_, renderSpan := tracer.Start(ctx, "Renderer.Render")
+	body, err := renderer.Render(response)
+	renderSpan.End()
+	if err != nil {
+		logger.Errorf("Error rendering the message, err: %s", err.Error())
+		recordError(span, err)
+		apierror.WriteProblem(ginContext, err)
+		return
+	}
+
+	ginContext.Data(http.StatusOK, renderer.ContentType(), body)
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
 }
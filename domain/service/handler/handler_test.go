@@ -1,17 +1,24 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/apierror"
 	"github.com/hortelanobruno/foaas-api/domain/model"
 	servicemocks "github.com/hortelanobruno/foaas-api/domain/service/mocks"
 	validatormocks "github.com/hortelanobruno/foaas-api/domain/validator/mocks"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 )
 
+// anyContext matches the context of the next layer, which carries the span of the caller.
+var anyContext = mock.Anything
+
 func TestHandleGetMessage(t *testing.T) {
 	cases := []struct {
 		name                 string
@@ -32,10 +39,11 @@ func TestHandleGetMessage(t *testing.T) {
 			}(),
 			nil,
 			http.StatusBadRequest,
-			`{"error":"an error"}`,
+			`{"type":"about:blank","title":"Bad Request","status":400,"detail":"an error","instance":"/",` +
+				`"code":"validation_error"}`,
 		},
 		{
-			"Should return an error when service returns an error",
+			"Should return an error without its message when service returns an internal error",
 			"123",
 			func() *validatormocks.MessageValidator {
 				mock := &validatormocks.MessageValidator{}
@@ -45,12 +53,109 @@ func TestHandleGetMessage(t *testing.T) {
 			}(),
 			func() *servicemocks.MessageService {
 				mock := &servicemocks.MessageService{}
-				mock.On("GetMessage", "123").
+				mock.On("GetMessage", anyContext, "asshole", "123").
 					Return(nil, fmt.Errorf("error getting message"))
 				return mock
 			}(),
 			http.StatusInternalServerError,
-			`{"error":"error getting message"}`,
+			`{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"detail":"The request couldn't be processed.","instance":"/","code":"internal_error"}`,
+		},
+		{
+			"Should return service unavailable when the circuit breaker is open",
+			"123",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").
+					Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "asshole", "123").
+					Return(nil, customhttp.ErrCircuitOpen)
+				return mock
+			}(),
+			http.StatusServiceUnavailable,
+			`{"type":"about:blank","title":"Service Unavailable","status":503,` +
+				`"detail":"foaas is unavailable for now.","instance":"/","code":"circuit_open"}`,
+		},
+		{
+			"Should return gateway timeout when foaas doesn't respond in time",
+			"123",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").
+					Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "asshole", "123").
+					Return(nil, apierror.New(apierror.KindTimeout, fmt.Errorf("error doing the request")))
+				return mock
+			}(),
+			http.StatusGatewayTimeout,
+			`{"type":"about:blank","title":"Gateway Timeout","status":504,` +
+				`"detail":"foaas didn't respond in time.","instance":"/","code":"upstream_timeout"}`,
+		},
+		{
+			"Should return client closed request when the client disconnects",
+			"123",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").
+					Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "asshole", "123").
+					Return(nil, fmt.Errorf("error getting the message, err: %w", context.Canceled))
+				return mock
+			}(),
+			apierror.StatusClientClosedRequest,
+			`{"type":"about:blank","title":"Client Closed Request","status":499,` +
+				`"detail":"The request was canceled.","instance":"/","code":"client_closed_request"}`,
+		},
+		{
+			"Should return bad gateway when foaas responds with a server error",
+			"123",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").
+					Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "asshole", "123").
+					Return(nil, apierror.NewUpstreamStatus(http.StatusInternalServerError,
+						fmt.Errorf("error executing request, status code: 500")))
+				return mock
+			}(),
+			http.StatusBadGateway,
+			`{"type":"about:blank","title":"Bad Gateway","status":502,` +
+				`"detail":"foaas failed to respond.","instance":"/","code":"upstream_server_error"}`,
+		},
+		{
+			"Should return bad gateway when the body of foaas can't be decoded",
+			"123",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").
+					Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "asshole", "123").
+					Return(nil, apierror.New(apierror.KindDecode, fmt.Errorf("error unmarshaling the body")))
+				return mock
+			}(),
+			http.StatusBadGateway,
+			`{"type":"about:blank","title":"Bad Gateway","status":502,` +
+				`"detail":"foaas sent an invalid body.","instance":"/","code":"upstream_decode_error"}`,
 		},
 		{
 			"Should return a nil error",
@@ -63,7 +168,7 @@ func TestHandleGetMessage(t *testing.T) {
 			}(),
 			func() *servicemocks.MessageService {
 				mock := &servicemocks.MessageService{}
-				mock.On("GetMessage", "123").
+				mock.On("GetMessage", anyContext, "asshole", "123").
 					Return(&model.Response{
 						Message:  "message",
 						Subtitle: "subtitle",
@@ -102,3 +207,200 @@ func TestHandleGetMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGetMessageContentNegotiation(t *testing.T) {
+	cases := []struct {
+		name                string
+		url                 string
+		accept              string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			"Should return json when accept header is empty",
+			"/message",
+			"",
+			http.StatusOK,
+			"application/json; charset=utf-8",
+			`{"message":"message","subtitle":"subtitle"}`,
+		},
+		{
+			"Should return text when accept header asks for text",
+			"/message",
+			"text/plain",
+			http.StatusOK,
+			"text/plain; charset=utf-8",
+			"message subtitle",
+		},
+		{
+			"Should return markdown when format query param overrides the accept header",
+			"/message?format=markdown",
+			"text/plain",
+			http.StatusOK,
+			"text/markdown; charset=utf-8",
+			"# message\n\n_subtitle_\n",
+		},
+		{
+			"Should return not acceptable when accept header is not supported",
+			"/message",
+			"image/gif",
+			http.StatusNotAcceptable,
+			"application/problem+json",
+			`{"type":"about:blank","title":"Not Acceptable","status":406,` +
+				`"detail":"none of the requested formats is supported","instance":"/message","code":"not_acceptable"}`,
+		},
+		{
+			"Should return not acceptable when format query param is not supported",
+			"/message?format=yaml",
+			"",
+			http.StatusNotAcceptable,
+			"application/problem+json",
+			`{"type":"about:blank","title":"Not Acceptable","status":406,` +
+				`"detail":"none of the requested formats is supported","instance":"/message","code":"not_acceptable"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			mockMessageValidator := &validatormocks.MessageValidator{}
+			mockMessageValidator.On("ValidateMessage", "123").Return(nil)
+			mockMessageService := &servicemocks.MessageService{}
+			mockMessageService.On("GetMessage", anyContext, "asshole", "123").
+				Return(&model.Response{
+					Message:  "message",
+					Subtitle: "subtitle",
+				}, nil)
+			handler := NewMessageHandler(mockMessageValidator, mockMessageService)
+
+			w := httptest.NewRecorder()
+			context, _ := gin.CreateTestContext(w)
+			context.Request, _ = http.NewRequest("GET", c.url, nil)
+			context.Request.Header.Set("UserId", "123")
+			context.Request.Header.Set("Accept", c.accept)
+
+			// Operation
+			handler.HandleGetMessage(context)
+
+			// Validation
+			assert.EqualValues(t, c.expectedStatusCode, w.Code)
+			assert.EqualValues(t, c.expectedContentType, w.Header().Get("Content-Type"))
+			assert.EqualValues(t, c.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandleGetMessageImage(t *testing.T) {
+	cases := []struct {
+		name                 string
+		operation            string
+		query                string
+		mockMessageValidator *validatormocks.MessageValidator
+		mockMessageService   *servicemocks.MessageService
+		expectedStatusCode   int
+		expectedContentType  string
+	}{
+		{
+			"Should return an error when validator returns an error for the operation",
+			"off.svg",
+			"",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").Return(nil)
+				mock.On("ValidateOperation", "off").Return(fmt.Errorf("an error"))
+				return mock
+			}(),
+			&servicemocks.MessageService{},
+			http.StatusBadRequest,
+			"application/problem+json",
+		},
+		{
+			"Should return an error when image options are invalid",
+			"asshole.svg",
+			"?theme=neon",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").Return(nil)
+				mock.On("ValidateOperation", "asshole").Return(nil)
+				return mock
+			}(),
+			&servicemocks.MessageService{},
+			http.StatusBadRequest,
+			"application/problem+json",
+		},
+		{
+			"Should return not found when the extension is not an image",
+			"asshole.gif",
+			"",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").Return(nil)
+				mock.On("ValidateOperation", "asshole").Return(nil)
+				return mock
+			}(),
+			&servicemocks.MessageService{},
+			http.StatusNotFound,
+			"application/problem+json",
+		},
+		{
+			"Should return an svg",
+			"asshole.svg",
+			"?width=400&height=200&theme=light",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").Return(nil)
+				mock.On("ValidateOperation", "asshole").Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "asshole", "123").
+					Return(&model.Response{Message: "message", Subtitle: "subtitle"}, nil)
+				return mock
+			}(),
+			http.StatusOK,
+			"image/svg+xml",
+		},
+		{
+			"Should return a png",
+			"bye.png",
+			"",
+			func() *validatormocks.MessageValidator {
+				mock := &validatormocks.MessageValidator{}
+				mock.On("ValidateMessage", "123").Return(nil)
+				mock.On("ValidateOperation", "bye").Return(nil)
+				return mock
+			}(),
+			func() *servicemocks.MessageService {
+				mock := &servicemocks.MessageService{}
+				mock.On("GetMessage", anyContext, "bye", "123").
+					Return(&model.Response{Message: "message", Subtitle: "subtitle"}, nil)
+				return mock
+			}(),
+			http.StatusOK,
+			"image/png",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			handler := NewMessageHandler(c.mockMessageValidator, c.mockMessageService)
+
+			w := httptest.NewRecorder()
+			context, _ := gin.CreateTestContext(w)
+			context.Request, _ = http.NewRequest("GET", "/message/"+c.operation+c.query, nil)
+			context.Request.Header.Set("UserId", "123")
+			context.Params = gin.Params{{Key: "operation", Value: c.operation}}
+
+			// Operation
+			handler.HandleGetMessageImage(context)
+
+			// Validation
+			assert.EqualValues(t, c.expectedStatusCode, w.Code)
+			assert.EqualValues(t, c.expectedContentType, w.Header().Get("Content-Type"))
+			c.mockMessageService.AssertExpectations(t)
+		})
+	}
+}
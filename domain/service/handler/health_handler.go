@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"net/http"
+)
+
+const (
+	statusUp   = "up"
+	statusDown = "down"
+)
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// HealthCheck checks whether a component the server depends on is ready.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	circuitBreaker *customhttp.CircuitBreakerClient
+	checks         []HealthCheck
+}
+
+// NewHealthHandler receives the circuit breaker of the foaas client, which is nil when it's disabled and
+// whose state is reported by the liveness, and the checks of the readiness.
+func NewHealthHandler(circuitBreaker *customhttp.CircuitBreakerClient, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		circuitBreaker: circuitBreaker,
+		checks:         checks,
+	}
+}
+
+// NewCircuitBreakerCheck fails the readiness while the circuit breaker is open.
+func NewCircuitBreakerCheck(circuitBreaker *customhttp.CircuitBreakerClient) HealthCheck {
+	return HealthCheck{
+		Name: "circuitBreaker",
+		Check: func(_ context.Context) error {
+			if circuitBreaker.State() == customhttp.StateOpen {
+				return errCircuitOpen
+			}
+			return nil
+		},
+	}
+}
+
+// WithChecks returns a copy of the handler with more checks of the readiness, so the handler can be shared
+// by servers with different checks.
+func (h *HealthHandler) WithChecks(checks ...HealthCheck) *HealthHandler {
+	allChecks := make([]HealthCheck, 0, len(h.checks)+len(checks))
+	allChecks = append(allChecks, h.checks...)
+	return &HealthHandler{
+		circuitBreaker: h.circuitBreaker,
+		checks:         append(allChecks, checks...),
+	}
+}
+
+// HandleHealth is the liveness check, it only fails when the server can't serve requests at all.
+func (h *HealthHandler) HandleHealth(ginContext *gin.Context) {
+	health := gin.H{
+		"status": "ok",
+	}
+	if h.circuitBreaker != nil {
+		health["circuitBreaker"] = h.circuitBreaker.State().String()
+	}
+
+	ginContext.JSON(http.StatusOK, health)
+}
+
+// HandleReadiness is the readiness check, which fails when any of the checks fails, with the status of
+// each one. The errors of the checks are only logged, since they have details of the infrastructure, like
+// the hosts of foaas.
+func (h *HealthHandler) HandleReadiness(ginContext *gin.Context) {
+	ctx := ginContext.Request.Context()
+	statusCode := http.StatusOK
+	status := "ready"
+	components := make(gin.H, len(h.checks))
+	for _, check := range h.checks {
+		component := gin.H{
+			"status": statusUp,
+		}
+		if err := check.Check(ctx); err != nil {
+			logging.FromContext(ctx).Warnf("Failing the readiness check %s, err: %s", check.Name, err.Error())
+			component["status"] = statusDown
+			statusCode = http.StatusServiceUnavailable
+			status = "not ready"
+		}
+		components[check.Name] = component
+	}
+
+	ginContext.JSON(statusCode, gin.H{
+		"status":     status,
+		"components": components,
+	})
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	httpmock "github.com/hortelanobruno/foaas-api/http/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleHealth(t *testing.T) {
+	cases := []struct {
+		name           string
+		circuitBreaker *customhttp.CircuitBreakerClient
+		expectedBody   string
+	}{
+		{
+			"Should return ok when the circuit breaker is disabled",
+			nil,
+			`{"status":"ok"}`,
+		},
+		{
+			"Should return the state of the circuit breaker when it's closed",
+			customhttp.NewCircuitBreakerClient(&httpmock.Client{}, 1, time.Minute, 1),
+			`{"circuitBreaker":"closed","status":"ok"}`,
+		},
+		{
+			"Should return the state of the circuit breaker when it's open",
+			openCircuitBreaker(),
+			`{"circuitBreaker":"open","status":"ok"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			handler := NewHealthHandler(c.circuitBreaker)
+
+			w := httptest.NewRecorder()
+			context, _ := gin.CreateTestContext(w)
+			context.Request, _ = http.NewRequest("GET", "/healthz", nil)
+
+			// Operation
+			handler.HandleHealth(context)
+
+			// Validation
+			assert.EqualValues(t, http.StatusOK, w.Code)
+			assert.EqualValues(t, c.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandleReadiness(t *testing.T) {
+	cases := []struct {
+		name               string
+		circuitBreaker     *customhttp.CircuitBreakerClient
+		checks             []HealthCheck
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			"Should return ready when there are no checks",
+			nil,
+			nil,
+			http.StatusOK,
+			`{"components":{},"status":"ready"}`,
+		},
+		{
+			"Should return ready when all the checks succeed",
+			nil,
+			[]HealthCheck{
+				NewCircuitBreakerCheck(customhttp.NewCircuitBreakerClient(&httpmock.Client{}, 1, time.Minute, 1)),
+				{Name: "upstream", Check: func(_ context.Context) error { return nil }},
+			},
+			http.StatusOK,
+			`{"components":{"circuitBreaker":{"status":"up"},"upstream":{"status":"up"}},"status":"ready"}`,
+		},
+		{
+			"Should return not ready with the errors of the checks that fail",
+			nil,
+			[]HealthCheck{
+				{Name: "rateLimiter", Check: func(_ context.Context) error { return nil }},
+				{Name: "upstream", Check: func(_ context.Context) error { return fmt.Errorf("foaas is down") }},
+			},
+			http.StatusServiceUnavailable,
+			`{"components":{"rateLimiter":{"status":"up"},"upstream":{"status":"down"}},` +
+				`"status":"not ready"}`,
+		},
+		{
+			"Should return not ready when the circuit breaker is open",
+			nil,
+			[]HealthCheck{
+				NewCircuitBreakerCheck(openCircuitBreaker()),
+			},
+			http.StatusServiceUnavailable,
+			`{"components":{"circuitBreaker":{"status":"down"}},` +
+				`"status":"not ready"}`,
+		},
+		{
+			"Should return ready when the circuit breaker is open but it's not checked",
+			openCircuitBreaker(),
+			nil,
+			http.StatusOK,
+			`{"components":{},"status":"ready"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			handler := NewHealthHandler(c.circuitBreaker, c.checks...)
+
+			w := httptest.NewRecorder()
+			context, _ := gin.CreateTestContext(w)
+			context.Request, _ = http.NewRequest("GET", "/readyz", nil)
+
+			// Operation
+			handler.HandleReadiness(context)
+
+			// Validation
+			assert.EqualValues(t, c.expectedStatusCode, w.Code)
+			assert.EqualValues(t, c.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestWithChecksShouldNotChangeTheChecksOfTheHandler(t *testing.T) {
+	// Initialization
+	handler := NewHealthHandler(nil, HealthCheck{Name: "upstream",
+		Check: func(_ context.Context) error { return nil }})
+	handleReadiness := func(handler *HealthHandler) string {
+		w := httptest.NewRecorder()
+		context, _ := gin.CreateTestContext(w)
+		context.Request, _ = http.NewRequest("GET", "/readyz", nil)
+		handler.HandleReadiness(context)
+		return w.Body.String()
+	}
+
+	// Operation
+	handlerWithChecks := handler.WithChecks(HealthCheck{Name: "shutdown",
+		Check: func(_ context.Context) error { return fmt.Errorf("server is shutting down") }})
+
+	// Validation
+	assert.EqualValues(t, `{"components":{"upstream":{"status":"up"}},"status":"ready"}`, handleReadiness(handler))
+	assert.EqualValues(t, `{"components":{"shutdown":{"status":"down"},`+
+		`"upstream":{"status":"up"}},"status":"not ready"}`, handleReadiness(handlerWithChecks))
+}
+
+func openCircuitBreaker() *customhttp.CircuitBreakerClient {
+	mock := &httpmock.Client{}
+	mock.On("Do", context.Background(), customhttp.NewRequest("https://foaas.com/asshole/123")).
+		Return(nil, fmt.Errorf("error doing the request"))
+	circuitBreaker := customhttp.NewCircuitBreakerClient(mock, 1, time.Minute, 1)
+	_, _ = circuitBreaker.Get(context.Background(), "https://foaas.com/asshole/123")
+	return circuitBreaker
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/hortelanobruno/foaas-api/domain/model"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,13 +14,13 @@ type MessageService struct {
 	mock.Mock
 }
 
-// GetMessage provides a mock function with given fields: userID
-func (_m *MessageService) GetMessage(userID string) (*domain.Response, error) {
-	ret := _m.Called(userID)
+// GetMessage provides a mock function with given fields: ctx, operation, userID
+func (_m *MessageService) GetMessage(ctx context.Context, operation string, userID string) (*domain.Response, error) {
+	ret := _m.Called(ctx, operation, userID)
 
 	var r0 *domain.Response
-	if rf, ok := ret.Get(0).(func(string) *domain.Response); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Response); ok {
+		r0 = rf(ctx, operation, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Response)
@@ -26,8 +28,8 @@ func (_m *MessageService) GetMessage(userID string) (*domain.Response, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, operation, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
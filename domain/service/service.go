@@ -1,9 +1,10 @@
 package service
 
 import (
+	"context"
 	"github.com/hortelanobruno/foaas-api/domain/model"
 )
 
 type MessageService interface {
-	GetMessage(userID string) (*model.Response, error)
+	GetMessage(ctx context.Context, operation, userID string) (*model.Response, error)
 }
@@ -1,40 +1,100 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/apierror"
 	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/hortelanobruno/foaas-api/domain/validator"
 	"github.com/hortelanobruno/foaas-api/http"
-	"github.com/sirupsen/logrus"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	"strings"
 )
 
 type MessageServiceImpl struct {
-	FoaasProtocol string
-	FoaasDomain   string
-	client        http.Client
+	baseURL           *url.URL
+	client            http.Client
+	responseValidator validator.ResponseValidator
 }
 
-func NewMessageServiceImpl(client http.Client) *MessageServiceImpl {
+// NewMessageServiceImpl builds a service that gets the messages from foaas at baseURL, like
+// https://foaas.com, which can have a path prefix.
+func NewMessageServiceImpl(baseURL *url.URL, client http.Client,
+	responseValidator validator.ResponseValidator) *MessageServiceImpl {
 	return &MessageServiceImpl{
-		FoaasProtocol: constants.FoaasProtocol,
-		FoaasDomain:   constants.FoaasDomain,
-		client:        client,
+		baseURL:           baseURL,
+		client:            client,
+		responseValidator: responseValidator,
 	}
 }
 
-func (m *MessageServiceImpl) GetMessage(userID string) (*model.Response, error) {
-	url := fmt.Sprintf("%s://%s/asshole/%s", m.FoaasProtocol, m.FoaasDomain, userID)
-	body, err := m.client.Get(url)
+var tracer = otel.Tracer("github.com/hortelanobruno/foaas-api/domain/service")
+
+func (m *MessageServiceImpl) GetMessage(ctx context.Context, operation, userID string) (*model.Response, error) {
+	ctx, span := tracer.Start(ctx, "MessageService.GetMessage",
+		trace.WithAttributes(attribute.String("foaas.operation", operation)))
+	defer span.End()
+
+	response, err := m.getMessage(ctx, operation, userID)
 	if err != nil {
-		return nil, err
+		recordError(span, err)
 	}
+	return response, err
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
 
+func (m *MessageServiceImpl) getMessage(ctx context.Context, operation, userID string) (*model.Response, error) {
 	response := &model.Response{}
-	if err := json.Unmarshal(body, response); err != nil {
-		logrus.Errorf("Error unmarshaling the response, err: %s", err.Error())
-		return nil, fmt.Errorf("error unmarshaling the body, err: %s", err.Error())
+	httpResponse, err := m.client.Do(ctx, http.NewRequest(m.messageURL(operation, userID)).
+		WithRoute(m.messageRoute()).
+		WithJSONTarget(response, true))
+	if err != nil {
+		return nil, err
+	}
+	if err := httpResponse.StatusError(); err != nil {
+		logging.FromContext(ctx).Errorf("Status code (%d) is different than OK", httpResponse.StatusCode)
+		return nil, err
+	}
+
+	response, err = m.responseValidator.ValidateResponse(response)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("Error validating the response, err: %s", err.Error())
+		return nil, apierror.New(apierror.KindInvalidResponse,
+			fmt.Errorf("error validating the body, err: %s", err.Error()))
 	}
 
 	return response, nil
 }
+
+// messageURL escapes the operation and the user ID, so that they can't change any other part of the url.
+func (m *MessageServiceImpl) messageURL(operation, userID string) string {
+	messageURL := *m.baseURL
+	messageURL.Path = strings.TrimSuffix(m.baseURL.Path, "/") + "/" + operation + "/" + userID
+	messageURL.RawPath = strings.TrimSuffix(m.baseURL.EscapedPath(), "/") + "/" + escapeSegment(operation) + "/" +
+		escapeSegment(userID)
+	return messageURL.String()
+}
+
+// messageRoute is the template of the path of the message urls, which is traced instead of the path, since
+// it has the user ID.
+func (m *MessageServiceImpl) messageRoute() string {
+	return strings.TrimSuffix(m.baseURL.EscapedPath(), "/") + "/:operation/:userID"
+}
+
+// escapeSegment escapes the segment of a path, including the dots of "." and "..", which url.PathEscape
+// leaves as they are, so that they are never resolved as dot segments whatever the reserved words are.
+func escapeSegment(segment string) string {
+	if segment == "." || segment == ".." {
+		return strings.ReplaceAll(segment, ".", "%2E")
+	}
+	return url.PathEscape(segment)
+}
@@ -1,73 +1,251 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
 	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/hortelanobruno/foaas-api/domain/validator"
+	validatormocks "github.com/hortelanobruno/foaas-api/domain/validator/mocks"
+	"github.com/hortelanobruno/foaas-api/http"
 	httpmock "github.com/hortelanobruno/foaas-api/http/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 )
 
+// anyContext matches the context of the next layer, which carries the span of the caller.
+var anyContext = mock.Anything
+
 func TestGetMessage(t *testing.T) {
+	messageRequest := mock.MatchedBy(func(request *http.Request) bool {
+		return request.URL == "https://foaas.com/asshole/123" && request.Route == "/:operation/:userID" &&
+			request.StrictJSON
+	})
+	respond := func(response model.Response) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			*args.Get(1).(*http.Request).JSONTarget.(*model.Response) = response
+		}
+	}
+
 	cases := []struct {
-		name             string
-		userID           string
-		mockClient       *httpmock.Client
-		expectedResponse *model.Response
-		expectedError    error
+		name                      string
+		operation                 string
+		userID                    string
+		mockClient                *httpmock.Client
+		mockResponseValidator     *validatormocks.ResponseValidator
+		expectedResponse          *model.Response
+		expectedError             error
+		expectedValidationsCalled int
 	}{
 		{
 			"Should return an error when client returns an error",
+			"asshole",
 			"123",
 			func() *httpmock.Client {
 				mock := &httpmock.Client{}
-				mock.On("Get", "https://foaas.com/asshole/123").
+				mock.On("Do", anyContext, messageRequest).
 					Return(nil, fmt.Errorf("error getting response from foaas"))
 				return mock
 			}(),
+			&validatormocks.ResponseValidator{},
 			nil,
 			fmt.Errorf("error getting response from foaas"),
+			0,
+		},
+		{
+			"Should return an error when status code is different than 200",
+			"asshole",
+			"123",
+			func() *httpmock.Client {
+				mock := &httpmock.Client{}
+				mock.On("Do", anyContext, messageRequest).
+					Return(&http.Response{StatusCode: 404}, nil)
+				return mock
+			}(),
+			&validatormocks.ResponseValidator{},
+			nil,
+			apierror.NewUpstreamStatus(404, fmt.Errorf("error executing request, status code: 404")),
+			0,
 		},
 		{
-			"Should return an error when there's an error unmarshalling the response",
+			"Should return an error when the response is not valid",
+			"asshole",
 			"123",
 			func() *httpmock.Client {
 				mock := &httpmock.Client{}
-				mock.On("Get", "https://foaas.com/asshole/123").
-					Return(nil, nil)
+				mock.On("Do", anyContext, messageRequest).
+					Run(respond(model.Response{Message: "", Subtitle: "- 123"})).
+					Return(&http.Response{StatusCode: 200}, nil)
+				return mock
+			}(),
+			func() *validatormocks.ResponseValidator {
+				mock := &validatormocks.ResponseValidator{}
+				mock.On("ValidateResponse", &model.Response{Message: "", Subtitle: "- 123"}).
+					Return(nil, fmt.Errorf("message can't be empty"))
 				return mock
 			}(),
 			nil,
-			fmt.Errorf("error unmarshaling the body, err: unexpected end of JSON input"),
+			apierror.New(apierror.KindInvalidResponse,
+				fmt.Errorf("error validating the body, err: message can't be empty")),
+			1,
 		},
 		{
-			"Should return an error when there's an error unmarshalling the response",
+			"Should return the validated response",
+			"asshole",
 			"123",
 			func() *httpmock.Client {
 				mock := &httpmock.Client{}
-				mock.On("Get", "https://foaas.com/asshole/123").
-					Return([]byte(`{"message": "Fuck you, asshole.","subtitle": "- 123"}`),
-						nil)
+				mock.On("Do", anyContext, messageRequest).
+					Run(respond(model.Response{Message: "Fuck you, <b>asshole</b>.", Subtitle: "- 123"})).
+					Return(&http.Response{StatusCode: 200}, nil)
+				return mock
+			}(),
+			func() *validatormocks.ResponseValidator {
+				mock := &validatormocks.ResponseValidator{}
+				mock.On("ValidateResponse", &model.Response{Message: "Fuck you, <b>asshole</b>.", Subtitle: "- 123"}).
+					Return(&model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"}, nil)
 				return mock
 			}(),
 			&model.Response{Message: "Fuck you, asshole.",
 				Subtitle: "- 123"},
 			nil,
+			1,
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			// Initialization
-			service := NewMessageServiceImpl(c.mockClient)
+			baseURL, _ := url.Parse("https://foaas.com")
+			service := NewMessageServiceImpl(baseURL, c.mockClient, c.mockResponseValidator)
 
 			// Operation
-			response, err := service.GetMessage(c.userID)
+			response, err := service.GetMessage(context.Background(), c.operation, c.userID)
 
 			// Validation
 			assert.EqualValues(t, c.expectedResponse, response)
 			assert.EqualValues(t, c.expectedError, err)
-			c.mockClient.AssertNumberOfCalls(t, "Get", 1)
+			c.mockClient.AssertNumberOfCalls(t, "Do", 1)
+			c.mockResponseValidator.AssertNumberOfCalls(t, "ValidateResponse", c.expectedValidationsCalled)
+		})
+	}
+}
+
+func TestMessageURL(t *testing.T) {
+	cases := []struct {
+		name        string
+		baseURL     string
+		userID      string
+		expectedURL string
+	}{
+		{
+			"Should return the url of the message",
+			"https://foaas.com",
+			"123",
+			"https://foaas.com/asshole/123",
+		},
+		{
+			"Should return the url of the message under the path of the base url",
+			"http://127.0.0.1:8080/foaas%20api/",
+			"123",
+			"http://127.0.0.1:8080/foaas%20api/asshole/123",
+		},
+		{
+			"Should escape the slashes and dots of the user id",
+			"https://foaas.com",
+			"../../off/you/123",
+			"https://foaas.com/asshole/..%2F..%2Foff%2Fyou%2F123",
+		},
+		{
+			"Should escape the user id when it's a dot segment",
+			"https://foaas.com",
+			"..",
+			"https://foaas.com/asshole/%2E%2E",
+		},
+		{
+			"Should escape the user id when it's the current segment",
+			"https://foaas.com",
+			".",
+			"https://foaas.com/asshole/%2E",
+		},
+		{
+			"Should escape the query and fragment of the user id",
+			"https://foaas.com",
+			"123?shoutcloud=true#top",
+			"https://foaas.com/asshole/123%3Fshoutcloud=true%23top",
+		},
+		{
+			"Should escape the spaces of the user id",
+			"https://foaas.com",
+			"José Pérez",
+			"https://foaas.com/asshole/Jos%C3%A9%20P%C3%A9rez",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			baseURL, _ := url.Parse(c.baseURL)
+			service := NewMessageServiceImpl(baseURL, &httpmock.Client{}, &validatormocks.ResponseValidator{})
+
+			// Operation
+			messageURL := service.messageURL("asshole", c.userID)
+
+			// Validation
+			assert.EqualValues(t, c.expectedURL, messageURL)
 		})
 	}
 }
+
+func TestGetMessageShouldNotRequestADotSegmentWhenTheReservedWordsAreTurnedOff(t *testing.T) {
+	// Initialization
+	requestedPaths := make(chan string, 1)
+	foaasServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		requestedPaths <- r.URL.EscapedPath()
+		_, _ = fmt.Fprint(w, `{"message": "Fuck you, asshole.","subtitle": "- .."}`)
+	}))
+	defer foaasServer.Close()
+
+	userID := ".."
+	messageValidator := validator.NewMessageValidatorImpl(validator.NewMaxLengthRule(64),
+		validator.NewReservedWordsRule(nil))
+	baseURL, _ := url.Parse(foaasServer.URL)
+	service := NewMessageServiceImpl(baseURL, http.NewClientImpl(time.Second),
+		validator.NewResponseValidatorImpl(1000, 200))
+
+	// Operation
+	validationErr := messageValidator.ValidateMessage(userID)
+	response, err := service.GetMessage(context.Background(), "asshole", userID)
+
+	// Validation
+	assert.Nil(t, validationErr)
+	assert.Nil(t, err)
+	assert.NotNil(t, response)
+	assert.EqualValues(t, "/asshole/%2E%2E", <-requestedPaths)
+}
+
+func FuzzMessageURL(f *testing.F) {
+	for _, userID := range []string{"123", "José", "..", "../off", "123?a=b", "123#a", "%2e%2e", "a/b", "\xff"} {
+		f.Add(userID)
+	}
+	baseURL, _ := url.Parse("https://foaas.com")
+	service := NewMessageServiceImpl(baseURL, &httpmock.Client{}, &validatormocks.ResponseValidator{})
+
+	f.Fuzz(func(t *testing.T, userID string) {
+		// Operation
+		messageURL, err := url.Parse(service.messageURL("asshole", userID))
+
+		// Validation
+		assert.Nil(t, err)
+		assert.EqualValues(t, "https", messageURL.Scheme)
+		assert.EqualValues(t, "foaas.com", messageURL.Host)
+		assert.EqualValues(t, "/asshole/"+userID, messageURL.Path)
+		assert.Empty(t, messageURL.RawQuery)
+		assert.Empty(t, messageURL.Fragment)
+	})
+}
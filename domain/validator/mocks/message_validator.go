@@ -22,3 +22,17 @@ func (_m *MessageValidator) ValidateMessage(userID string) error {
 
 	return r0
 }
+
+// ValidateOperation provides a mock function with given fields: operation
+func (_m *MessageValidator) ValidateOperation(operation string) error {
+	ret := _m.Called(operation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(operation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
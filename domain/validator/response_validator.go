@@ -0,0 +1,8 @@
+package validator
+
+import "github.com/hortelanobruno/foaas-api/domain/model"
+
+type ResponseValidator interface {
+	// ValidateResponse checks the response of foaas and returns it without HTML.
+	ValidateResponse(response *model.Response) (*model.Response, error)
+}
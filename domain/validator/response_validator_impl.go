@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	scriptPattern = regexp.MustCompile(`(?is)<(script|style)\b[^>]*>.*?</(script|style)\s*>`)
+	tagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+type ResponseValidatorImpl struct {
+	maxMessageLength  int
+	maxSubtitleLength int
+}
+
+func NewResponseValidatorImpl(maxMessageLength, maxSubtitleLength int) *ResponseValidatorImpl {
+	return &ResponseValidatorImpl{
+		maxMessageLength:  maxMessageLength,
+		maxSubtitleLength: maxSubtitleLength,
+	}
+}
+
+func (r *ResponseValidatorImpl) ValidateResponse(response *model.Response) (*model.Response, error) {
+	message, err := validateText("message", response.Message, r.maxMessageLength)
+	if err != nil {
+		return nil, err
+	}
+	if message == "" {
+		return nil, fmt.Errorf("message can't be empty")
+	}
+
+	subtitle, err := validateText("subtitle", response.Subtitle, r.maxSubtitleLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Response{
+		Message:  message,
+		Subtitle: subtitle,
+	}, nil
+}
+
+// validateText sanitizes the text before checking its length, the user ID that foaas echoes back could
+// carry markup.
+func validateText(field, text string, maxLength int) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", fmt.Errorf("%s is not valid UTF-8", field)
+	}
+
+	text = sanitize(text)
+	if utf8.RuneCountInString(text) > maxLength {
+		return "", fmt.Errorf("%s can't be longer than %d characters", field, maxLength)
+	}
+	return text, nil
+}
+
+// sanitize removes the scripts, styles, tags and control characters of the text.
+func sanitize(text string) string {
+	text = scriptPattern.ReplaceAllString(text, "")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text)
+}
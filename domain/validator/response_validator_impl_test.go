@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/domain/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestValidateResponse(t *testing.T) {
+	cases := []struct {
+		name             string
+		input            *model.Response
+		expectedResponse *model.Response
+		expectedError    error
+	}{
+		{
+			name:          "Should return an error when message is empty",
+			input:         &model.Response{Message: "", Subtitle: "- 123"},
+			expectedError: fmt.Errorf("message can't be empty"),
+		},
+		{
+			name:          "Should return an error when message is empty once sanitized",
+			input:         &model.Response{Message: "<script>alert(1)</script>", Subtitle: "- 123"},
+			expectedError: fmt.Errorf("message can't be empty"),
+		},
+		{
+			name:          "Should return an error when message is too long",
+			input:         &model.Response{Message: strings.Repeat("a", 31), Subtitle: "- 123"},
+			expectedError: fmt.Errorf("message can't be longer than 30 characters"),
+		},
+		{
+			name:          "Should return an error when subtitle is too long",
+			input:         &model.Response{Message: "Fuck you, asshole.", Subtitle: strings.Repeat("a", 11)},
+			expectedError: fmt.Errorf("subtitle can't be longer than 10 characters"),
+		},
+		{
+			name:          "Should return an error when message is not valid UTF-8",
+			input:         &model.Response{Message: "Fuck you, \xff.", Subtitle: "- 123"},
+			expectedError: fmt.Errorf("message is not valid UTF-8"),
+		},
+		{
+			name:          "Should return an error when subtitle is not valid UTF-8",
+			input:         &model.Response{Message: "Fuck you, asshole.", Subtitle: "- \xff"},
+			expectedError: fmt.Errorf("subtitle is not valid UTF-8"),
+		},
+		{
+			name: "Should return the response without scripts nor tags",
+			input: &model.Response{
+				Message:  `Fuck you, <b onclick="x()">asshole</b>.<script>alert(1)</script>`,
+				Subtitle: "- <STYLE>p{}</STYLE><i>123</i>\x00",
+			},
+			expectedResponse: &model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"},
+		},
+		{
+			name:             "Should return the response when it's valid",
+			input:            &model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"},
+			expectedResponse: &model.Response{Message: "Fuck you, asshole.", Subtitle: "- 123"},
+		},
+	}
+
+	responseValidator := NewResponseValidatorImpl(30, 10)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			// Operation
+			response, err := responseValidator.ValidateResponse(c.input)
+
+			// Validation
+			assert.EqualValues(t, c.expectedResponse, response)
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule checks a user ID, returning the reason why it's not valid.
+type Rule func(userID string) error
+
+// NewMaxLengthRule rejects the user IDs with more than maxLength characters.
+func NewMaxLengthRule(maxLength int) Rule {
+	return func(userID string) error {
+		if utf8.RuneCountInString(userID) > maxLength {
+			return fmt.Errorf("userID can't be longer than %d characters", maxLength)
+		}
+		return nil
+	}
+}
+
+// NewCharsetRule rejects the user IDs with characters outside the charset, which is the content of a
+// regular expression character class, like `a-z0-9_`.
+func NewCharsetRule(charset string) (Rule, error) {
+	pattern, err := regexp.Compile("^[" + charset + "]*$")
+	if err != nil {
+		return nil, fmt.Errorf("charset %q is not valid, err: %s", charset, err.Error())
+	}
+	return func(userID string) error {
+		if !utf8.ValidString(userID) || !pattern.MatchString(userID) {
+			return fmt.Errorf("userID can only contain the characters %s", charset)
+		}
+		return nil
+	}, nil
+}
+
+// NewReservedWordsRule rejects the user IDs that are one of the words, ignoring the case.
+func NewReservedWordsRule(words []string) Rule {
+	reserved := make(map[string]bool, len(words))
+	for _, word := range words {
+		reserved[strings.ToLower(word)] = true
+	}
+	return func(userID string) error {
+		if reserved[strings.ToLower(userID)] {
+			return fmt.Errorf("userID %q is reserved", userID)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	charsetRule, _ := NewCharsetRule(`\p{L}\p{N} ._-`)
+	cases := []struct {
+		name           string
+		rule           Rule
+		input          string
+		expectedOutput error
+	}{
+		{
+			name:           "Should return an error when user id is longer than the max length",
+			rule:           NewMaxLengthRule(3),
+			input:          "1234",
+			expectedOutput: fmt.Errorf("userID can't be longer than 3 characters"),
+		},
+		{
+			name:           "Should return a nil error when user id has the max length in characters",
+			rule:           NewMaxLengthRule(4),
+			input:          "José",
+			expectedOutput: nil,
+		},
+		{
+			name:           "Should return an error when user id has a slash",
+			rule:           charsetRule,
+			input:          "123/../off",
+			expectedOutput: fmt.Errorf(`userID can only contain the characters \p{L}\p{N} ._-`),
+		},
+		{
+			name:           "Should return an error when user id has a query",
+			rule:           charsetRule,
+			input:          "123?shoutcloud=true",
+			expectedOutput: fmt.Errorf(`userID can only contain the characters \p{L}\p{N} ._-`),
+		},
+		{
+			name:           "Should return an error when user id has a fragment",
+			rule:           charsetRule,
+			input:          "123#top",
+			expectedOutput: fmt.Errorf(`userID can only contain the characters \p{L}\p{N} ._-`),
+		},
+		{
+			name:           "Should return an error when user id is not valid UTF-8",
+			rule:           charsetRule,
+			input:          "12\xff",
+			expectedOutput: fmt.Errorf(`userID can only contain the characters \p{L}\p{N} ._-`),
+		},
+		{
+			name:           "Should return a nil error when user id is in the charset",
+			rule:           charsetRule,
+			input:          "José Pérez_1.0",
+			expectedOutput: nil,
+		},
+		{
+			name:           "Should return an error when user id is a reserved word",
+			rule:           NewReservedWordsRule([]string{".", "..", "Version"}),
+			input:          "..",
+			expectedOutput: fmt.Errorf(`userID ".." is reserved`),
+		},
+		{
+			name:           "Should return an error when user id is a reserved word in another case",
+			rule:           NewReservedWordsRule([]string{".", "..", "Version"}),
+			input:          "VERSION",
+			expectedOutput: fmt.Errorf(`userID "VERSION" is reserved`),
+		},
+		{
+			name:           "Should return a nil error when user id is not a reserved word",
+			rule:           NewReservedWordsRule([]string{".", "..", "Version"}),
+			input:          "...",
+			expectedOutput: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			// Operation
+			output := c.rule(c.input)
+
+			// Validation
+			assert.EqualValues(t, c.expectedOutput, output)
+		})
+	}
+}
+
+func TestNewCharsetRule(t *testing.T) {
+	// Operation
+	rule, err := NewCharsetRule(`z-a`)
+
+	// Validation
+	assert.Nil(t, rule)
+	assert.EqualValues(t, fmt.Errorf("charset \"z-a\" is not valid, err: error parsing regexp: "+
+		"invalid character class range: `z-a`"), err)
+}
@@ -2,4 +2,5 @@ package validator
 
 type MessageValidator interface {
 	ValidateMessage(userID string) error
+	ValidateOperation(operation string) error
 }
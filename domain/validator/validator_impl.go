@@ -1,17 +1,45 @@
 package validator
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/constants"
+)
 
-type MessageValidatorImpl struct{}
+type MessageValidatorImpl struct {
+	operations map[string]bool
+	rules      []Rule
+}
 
-func NewMessageValidatorImpl() *MessageValidatorImpl {
-	return &MessageValidatorImpl{}
+// NewMessageValidatorImpl builds a validator where the user IDs can't be empty and must follow all the rules.
+func NewMessageValidatorImpl(rules ...Rule) *MessageValidatorImpl {
+	operations := make(map[string]bool, len(constants.FoaasOperations))
+	for _, operation := range constants.FoaasOperations {
+		operations[operation] = true
+	}
+	return &MessageValidatorImpl{
+		operations: operations,
+		rules:      rules,
+	}
 }
 
-func (*MessageValidatorImpl) ValidateMessage(userID string) error {
+func (m *MessageValidatorImpl) ValidateMessage(userID string) error {
 	if userID == "" {
 		return fmt.Errorf("userID can't be empty")
 	}
 
+	for _, rule := range m.rules {
+		if err := rule(userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MessageValidatorImpl) ValidateOperation(operation string) error {
+	if !m.operations[operation] {
+		return fmt.Errorf("operation %q is not supported", operation)
+	}
+
 	return nil
 }
@@ -3,7 +3,9 @@ package validator
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestValidateMessage(t *testing.T) {
@@ -17,6 +19,16 @@ func TestValidateMessage(t *testing.T) {
 			input:          "",
 			expectedOutput: fmt.Errorf("userID can't be empty"),
 		},
+		{
+			name:           "Should return an error when user id breaks a rule",
+			input:          "../off",
+			expectedOutput: fmt.Errorf("userID can only contain the characters a-z0-9 ."),
+		},
+		{
+			name:           "Should return an error when user id breaks the first rule",
+			input:          "..",
+			expectedOutput: fmt.Errorf(`userID ".." is reserved`),
+		},
 		{
 			name:           "Should return a nil error when user id not empty",
 			input:          "valid user id",
@@ -24,7 +36,8 @@ func TestValidateMessage(t *testing.T) {
 		},
 	}
 
-	messageValidator := NewMessageValidatorImpl()
+	charsetRule, _ := NewCharsetRule("a-z0-9 .")
+	messageValidator := NewMessageValidatorImpl(NewReservedWordsRule([]string{".", ".."}), charsetRule)
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 
@@ -36,3 +49,63 @@ func TestValidateMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateOperation(t *testing.T) {
+	cases := []struct {
+		name           string
+		input          string
+		expectedOutput error
+	}{
+		{
+			name:           "Should return an error when operation is empty",
+			input:          "",
+			expectedOutput: fmt.Errorf(`operation "" is not supported`),
+		},
+		{
+			name:           "Should return an error when operation is not a foaas operation",
+			input:          "off",
+			expectedOutput: fmt.Errorf(`operation "off" is not supported`),
+		},
+		{
+			name:           "Should return a nil error when operation is a foaas operation",
+			input:          "asshole",
+			expectedOutput: nil,
+		},
+	}
+
+	messageValidator := NewMessageValidatorImpl()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			// Operation
+			output := messageValidator.ValidateOperation(c.input)
+
+			// Validation
+			assert.EqualValues(t, c.expectedOutput, output)
+		})
+	}
+}
+
+func FuzzValidateMessage(f *testing.F) {
+	for _, userID := range []string{"123", "José", ".", "..", "../off", "123?a=b", "123#a", "%2e%2e", "a/b", "\xff"} {
+		f.Add(userID)
+	}
+	charsetRule, _ := NewCharsetRule(`\p{L}\p{N} ._@-`)
+	messageValidator := NewMessageValidatorImpl(NewMaxLengthRule(64), charsetRule,
+		NewReservedWordsRule([]string{".", ".."}))
+
+	f.Fuzz(func(t *testing.T, userID string) {
+		// Operation
+		err := messageValidator.ValidateMessage(userID)
+
+		// Validation
+		if err != nil {
+			return
+		}
+		assert.NotEmpty(t, userID)
+		assert.True(t, utf8.ValidString(userID))
+		assert.LessOrEqual(t, utf8.RuneCountInString(userID), 64)
+		assert.False(t, strings.ContainsAny(userID, "/\\?#%"), "userID %q has a character of the url syntax", userID)
+		assert.NotContains(t, []string{".", ".."}, userID)
+	})
+}
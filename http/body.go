@@ -0,0 +1,98 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"io"
+)
+
+const maxLoggedBodySize = 512
+
+// readError wraps the errors of the connection while reading a body, to tell them apart from the ones of
+// decoding it.
+type readError struct {
+	err error
+}
+
+func (r *readError) Error() string {
+	return r.err.Error()
+}
+
+func (r *readError) Unwrap() error {
+	return r.err
+}
+
+// boundedReader fails with an error of kind apierror.KindResponseTooLarge as soon as the body has more
+// than max bytes.
+type boundedReader struct {
+	reader    io.Reader
+	max       int64
+	remaining int64
+}
+
+func newBoundedReader(reader io.Reader, max int64) *boundedReader {
+	return &boundedReader{
+		reader:    reader,
+		max:       max,
+		remaining: max,
+	}
+}
+
+func (b *boundedReader) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Only a body that goes on after the limit is too large.
+		var next [1]byte
+		n, err := b.reader.Read(next[:])
+		if n > 0 {
+			return 0, apierror.New(apierror.KindResponseTooLarge,
+				fmt.Errorf("response body exceeds the maximum size of %d bytes", b.max))
+		}
+		return 0, wrapReadError(err)
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+
+	n, err := b.reader.Read(p)
+	b.remaining -= int64(n)
+	return n, wrapReadError(err)
+}
+
+func wrapReadError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return &readError{err: err}
+}
+
+// bodyRecorder keeps the beginning of a body while it's read, to log it.
+type bodyRecorder struct {
+	reader   io.Reader
+	recorded bytes.Buffer
+	size     int64
+}
+
+func (b *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if missing := maxLoggedBodySize - b.recorded.Len(); missing > 0 {
+		if missing > n {
+			missing = n
+		}
+		b.recorded.Write(p[:missing])
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *bodyRecorder) String() string {
+	return describeBody(b.recorded.Bytes(), b.size)
+}
+
+// describeBody returns the body to log, truncated when it's too long.
+func describeBody(body []byte, size int64) string {
+	if size <= maxLoggedBodySize {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (truncated, %d bytes)", body[:maxLoggedBodySize], size)
+}
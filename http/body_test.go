@@ -0,0 +1,107 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestBoundedReader(t *testing.T) {
+	cases := []struct {
+		name          string
+		body          string
+		max           int64
+		expectedBody  []byte
+		expectedError error
+	}{
+		{
+			"Should read the whole body when it's smaller than the maximum size",
+			"unit testing",
+			64,
+			[]byte("unit testing"),
+			nil,
+		},
+		{
+			"Should read the whole body when it has exactly the maximum size",
+			"unit testing",
+			12,
+			[]byte("unit testing"),
+			nil,
+		},
+		{
+			"Should return an error when the body exceeds the maximum size",
+			"unit testing",
+			11,
+			[]byte("unit testin"),
+			apierror.New(apierror.KindResponseTooLarge,
+				fmt.Errorf("response body exceeds the maximum size of 11 bytes")),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			reader := newBoundedReader(strings.NewReader(c.body), c.max)
+
+			// Operation
+			body, err := ioutil.ReadAll(reader)
+
+			// Validation
+			assert.EqualValues(t, c.expectedBody, body)
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
+
+func TestBoundedReaderShouldWrapTheReadErrors(t *testing.T) {
+	// Initialization
+	readCloserMock := &mockReadCloser{}
+	readCloserMock.On("Read", mock.Anything).Return(0, fmt.Errorf("error reading"))
+	reader := newBoundedReader(readCloserMock, 12)
+
+	// Operation
+	_, err := reader.Read(make([]byte, 12))
+
+	// Validation
+	assert.EqualValues(t, &readError{err: fmt.Errorf("error reading")}, err)
+}
+
+func TestBodyRecorder(t *testing.T) {
+	cases := []struct {
+		name           string
+		body           []byte
+		expectedLogged string
+	}{
+		{
+			"Should return the whole body when it's short",
+			[]byte("unit testing"),
+			"unit testing",
+		},
+		{
+			"Should return the beginning of the body when it's too long",
+			bytes.Repeat([]byte("a"), maxLoggedBodySize+100),
+			strings.Repeat("a", maxLoggedBodySize) + "... (truncated, 612 bytes)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			recorder := &bodyRecorder{reader: bytes.NewReader(c.body)}
+
+			// Operation
+			body, err := ioutil.ReadAll(recorder)
+
+			// Validation
+			assert.Nil(t, err)
+			assert.EqualValues(t, c.body, body)
+			assert.EqualValues(t, c.expectedLogged, recorder.String())
+			assert.EqualValues(t, c.expectedLogged, describeBody(c.body, int64(len(c.body))))
+		})
+	}
+}
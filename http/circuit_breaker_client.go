@@ -0,0 +1,163 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = apierror.New(apierror.KindCircuitOpen, errors.New("circuit breaker is open"))
+
+type CircuitState int
+
+const (
+	StateClosed CircuitState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerClient decorates a Client, failing fast with ErrCircuitOpen after failureThreshold
+// consecutive failures. Once the cool down has passed, up to halfOpenMaxRequests trial requests are let
+// through: the circuit closes when all of them succeed and opens again as soon as one fails.
+type CircuitBreakerClient struct {
+	client              Client
+	failureThreshold    int
+	coolDown            time.Duration
+	halfOpenMaxRequests int
+	state               CircuitState
+	failures            int
+	halfOpenRequests    int
+	halfOpenSuccesses   int
+	openedAt            time.Time
+	mutex               *sync.Mutex
+	now                 func() time.Time
+}
+
+func NewCircuitBreakerClient(client Client, failureThreshold int, coolDown time.Duration,
+	halfOpenMaxRequests int) *CircuitBreakerClient {
+	return &CircuitBreakerClient{
+		client:              client,
+		failureThreshold:    failureThreshold,
+		coolDown:            coolDown,
+		halfOpenMaxRequests: halfOpenMaxRequests,
+		state:               StateClosed,
+		mutex:               &sync.Mutex{},
+		now:                 time.Now,
+	}
+}
+
+func (c *CircuitBreakerClient) Get(ctx context.Context, url string) ([]byte, error) {
+	return get(ctx, c, url)
+}
+
+// Do counts the errors and the server error responses as failures, client error responses say nothing about
+// the health of foaas.
+func (c *CircuitBreakerClient) Do(ctx context.Context, request *Request) (*Response, error) {
+	if !c.allowRequest() {
+		logging.FromContext(ctx).Errorf("Circuit breaker is open, rejecting request for url: %s", request.URL)
+		return nil, ErrCircuitOpen
+	}
+
+	response, err := c.client.Do(ctx, request)
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// The caller gave up, which says nothing about the health of foaas.
+		c.releaseRequest()
+		return nil, err
+	}
+
+	c.recordResult(err != nil || response.StatusCode >= http.StatusInternalServerError)
+	return response, err
+}
+
+func (c *CircuitBreakerClient) State() CircuitState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.state
+}
+
+func (c *CircuitBreakerClient) allowRequest() bool {
+	now := c.now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.state == StateOpen {
+		if now.Sub(c.openedAt) < c.coolDown {
+			return false
+		}
+		c.transition(StateHalfOpen, now)
+	}
+
+	if c.state == StateHalfOpen {
+		if c.halfOpenRequests >= c.halfOpenMaxRequests {
+			return false
+		}
+		c.halfOpenRequests++
+	}
+	return true
+}
+
+// releaseRequest frees the trial request slot of a request without result.
+func (c *CircuitBreakerClient) releaseRequest() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.state == StateHalfOpen && c.halfOpenRequests > 0 {
+		c.halfOpenRequests--
+	}
+}
+
+func (c *CircuitBreakerClient) recordResult(failed bool) {
+	now := c.now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch c.state {
+	case StateClosed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= c.failureThreshold {
+			c.transition(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failed {
+			c.transition(StateOpen, now)
+			return
+		}
+		c.halfOpenSuccesses++
+		if c.halfOpenSuccesses >= c.halfOpenMaxRequests {
+			c.transition(StateClosed, now)
+		}
+	}
+}
+
+func (c *CircuitBreakerClient) transition(state CircuitState, now time.Time) {
+	logrus.Warnf("Circuit breaker changing state from %s to %s", c.state, state)
+	c.state = state
+	c.failures = 0
+	c.halfOpenRequests = 0
+	c.halfOpenSuccesses = 0
+	if state == StateOpen {
+		c.openedAt = now
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const circuitBreakerURL = "https://foaas.com/asshole/123"
+
+func newTestCircuitBreakerClient(client Client, now *time.Time) *CircuitBreakerClient {
+	circuitBreaker := NewCircuitBreakerClient(client, 2, 10*time.Second, 1)
+	circuitBreaker.now = func() time.Time {
+		return *now
+	}
+	return circuitBreaker
+}
+
+func TestCircuitBreakerShouldOpenWhenFailuresReachTheThreshold(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	client := &mockClient{}
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).Return(nil, fmt.Errorf("error doing the request"))
+	circuitBreaker := newTestCircuitBreakerClient(client, &now)
+
+	// Operation
+	_, errAttempt1 := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	stateAfterAttempt1 := circuitBreaker.State()
+	_, errAttempt2 := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	_, errAttempt3 := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+	// Validation
+	assert.EqualValues(t, fmt.Errorf("error doing the request"), errAttempt1)
+	assert.EqualValues(t, StateClosed, stateAfterAttempt1)
+	assert.EqualValues(t, fmt.Errorf("error doing the request"), errAttempt2)
+	assert.EqualValues(t, ErrCircuitOpen, errAttempt3)
+	assert.EqualValues(t, StateOpen, circuitBreaker.State())
+	client.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestCircuitBreakerShouldResetFailuresAfterASuccess(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	client := &mockClient{}
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).
+		Return(nil, fmt.Errorf("error doing the request")).Once()
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).
+		Return(&Response{StatusCode: http.StatusOK, Body: []byte(`{}`)}, nil).Once()
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).
+		Return(nil, fmt.Errorf("error doing the request")).Once()
+	circuitBreaker := newTestCircuitBreakerClient(client, &now)
+
+	// Operation
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+	// Validation
+	assert.EqualValues(t, StateClosed, circuitBreaker.State())
+	client.AssertNumberOfCalls(t, "Do", 3)
+}
+
+func TestCircuitBreakerShouldCloseWhenTrialRequestSucceedsAfterCoolDown(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	client := &mockClient{}
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).
+		Return(nil, fmt.Errorf("error doing the request")).Twice()
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).
+		Return(&Response{StatusCode: http.StatusOK, Body: []byte(`{}`)}, nil).Once()
+	circuitBreaker := newTestCircuitBreakerClient(client, &now)
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+	// Operation
+	now = now.Add(9 * time.Second)
+	_, errBeforeCoolDown := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	now = now.Add(time.Second)
+	body, errAfterCoolDown := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+	// Validation
+	assert.EqualValues(t, ErrCircuitOpen, errBeforeCoolDown)
+	assert.Nil(t, errAfterCoolDown)
+	assert.EqualValues(t, []byte(`{}`), body)
+	assert.EqualValues(t, StateClosed, circuitBreaker.State())
+	client.AssertNumberOfCalls(t, "Do", 3)
+}
+
+func TestCircuitBreakerShouldOpenAgainWhenTrialRequestFails(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	client := &mockClient{}
+	client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).Return(nil, fmt.Errorf("error doing the request"))
+	circuitBreaker := newTestCircuitBreakerClient(client, &now)
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+	// Operation
+	now = now.Add(10 * time.Second)
+	_, errTrial := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+	_, errAfterTrial := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+	// Validation
+	assert.EqualValues(t, fmt.Errorf("error doing the request"), errTrial)
+	assert.EqualValues(t, ErrCircuitOpen, errAfterTrial)
+	assert.EqualValues(t, StateOpen, circuitBreaker.State())
+	client.AssertNumberOfCalls(t, "Do", 3)
+}
+
+func TestCircuitBreakerShouldLimitTheTrialRequestsWhenHalfOpen(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	circuitBreaker := newTestCircuitBreakerClient(&mockClient{}, &now)
+	circuitBreaker.state = StateOpen
+	circuitBreaker.openedAt = now.Add(-10 * time.Second)
+
+	// Operation
+	allowedTrial := circuitBreaker.allowRequest()
+	allowedWhileTrialInFlight := circuitBreaker.allowRequest()
+
+	// Validation
+	assert.True(t, allowedTrial)
+	assert.False(t, allowedWhileTrialInFlight)
+	assert.EqualValues(t, StateHalfOpen, circuitBreaker.State())
+}
+
+func TestCircuitBreakerShouldIgnoreRequestsCanceledByTheCaller(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := &mockClient{}
+	client.On("Do", ctx, NewRequest(circuitBreakerURL)).Return(nil, context.Canceled)
+	circuitBreaker := newTestCircuitBreakerClient(client, &now)
+	circuitBreaker.state = StateHalfOpen
+
+	// Operation
+	_, err := circuitBreaker.Get(ctx, circuitBreakerURL)
+
+	// Validation
+	assert.EqualValues(t, context.Canceled, err)
+	assert.EqualValues(t, StateHalfOpen, circuitBreaker.State())
+	assert.EqualValues(t, 0, circuitBreaker.halfOpenRequests)
+}
+
+func TestCircuitBreakerShouldCountOnlyServerErrorResponsesAsFailures(t *testing.T) {
+	cases := []struct {
+		name          string
+		statusCode    int
+		expectedState CircuitState
+	}{
+		{
+			"Should open when foaas responds with server errors",
+			http.StatusBadGateway,
+			StateOpen,
+		},
+		{
+			"Should stay closed when foaas responds with client errors",
+			http.StatusNotFound,
+			StateClosed,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+			client := &mockClient{}
+			client.On("Do", mock.Anything, NewRequest(circuitBreakerURL)).
+				Return(&Response{StatusCode: c.statusCode}, nil)
+			circuitBreaker := newTestCircuitBreakerClient(client, &now)
+
+			// Operation
+			_, err := circuitBreaker.Get(context.Background(), circuitBreakerURL)
+			_, _ = circuitBreaker.Get(context.Background(), circuitBreakerURL)
+
+			// Validation
+			assert.EqualValues(t, apierror.NewUpstreamStatus(c.statusCode,
+				fmt.Errorf("error executing request, status code: %d", c.statusCode)), err)
+			assert.EqualValues(t, c.expectedState, circuitBreaker.State())
+		})
+	}
+}
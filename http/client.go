@@ -1,5 +1,20 @@
 package http
 
+import (
+	"context"
+	"time"
+)
+
 type Client interface {
-	Get(url string) ([]byte, error)
+	// Do executes the request and returns the response whatever its status code is. The error is only
+	// set when there's no response.
+	Do(ctx context.Context, request *Request) (*Response, error)
+	// Get is a shortcut of Do for GET requests, which fails when the status code isn't OK.
+	Get(ctx context.Context, url string) ([]byte, error)
+}
+
+// AttemptObserver is notified of each attempt to execute a request, where either the response or the error
+// is set, to gather metrics.
+type AttemptObserver interface {
+	ObserveAttempt(duration time.Duration, response *Response, err error)
 }
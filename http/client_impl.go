@@ -1,108 +1,466 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/hortelanobruno/foaas-api/constants"
-	"github.com/sirupsen/logrus"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"reflect"
 	"time"
 )
 
+const defaultMaxResponseSize = 1 << 20
+
 type ClientImpl struct {
-	client *http.Client
+	client          *http.Client
+	timeout         time.Duration
+	maxResponseSize int64
+	retryPolicy     RetryPolicy
+	hedgingPolicy   HedgingPolicy
+	hedgingBudget   *hedgingBudget
+	latencies       *latencyTracker
+	observer        AttemptObserver
+	now             func() time.Time
+	after           func(time.Duration) <-chan time.Time
+	random          func() float64
+}
+
+type attemptResult struct {
+	response  *Response
+	retryable bool
+	err       error
+}
+
+type ClientOption func(*ClientImpl)
+
+func WithRetryPolicy(retryPolicy RetryPolicy) ClientOption {
+	return func(c *ClientImpl) {
+		c.retryPolicy = retryPolicy
+	}
+}
+
+// WithTransport replaces the default transport of the client, see NewTransport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *ClientImpl) {
+		c.client.Transport = transport
+	}
+}
+
+// WithAttemptObserver notifies the observer of every attempt, including the retries and the hedged ones.
+func WithAttemptObserver(observer AttemptObserver) ClientOption {
+	return func(c *ClientImpl) {
+		c.observer = observer
+	}
 }
 
-func NewClientImpl(timeout time.Duration) *ClientImpl {
+// WithMaxResponseSize limits the size of the bodies of the responses. The size must be positive, otherwise
+// the default limit of 1 MiB is kept, since the bodies are always bounded.
+func WithMaxResponseSize(maxResponseSize int64) ClientOption {
+	return func(c *ClientImpl) {
+		if maxResponseSize > 0 {
+			c.maxResponseSize = maxResponseSize
+		}
+	}
+}
+
+func WithHedgingPolicy(hedgingPolicy HedgingPolicy) ClientOption {
+	return func(c *ClientImpl) {
+		c.hedgingPolicy = hedgingPolicy
+		c.hedgingBudget = newHedgingBudget(hedgingPolicy.BudgetPercent)
+	}
+}
+
+// NewClientImpl builds a client where the timeout bounds each request including all its retries.
+func NewClientImpl(timeout time.Duration, options ...ClientOption) *ClientImpl {
 	client := &http.Client{
 		Timeout: timeout,
 	}
-	return &ClientImpl{
-		client: client,
+	clientImpl := &ClientImpl{
+		client:          client,
+		timeout:         timeout,
+		maxResponseSize: defaultMaxResponseSize,
+		retryPolicy:     NoRetryPolicy(),
+		latencies:       newLatencyTracker(),
+		now:             time.Now,
+		after:           time.After,
+		random:          rand.Float64,
 	}
+	for _, option := range options {
+		option(clientImpl)
+	}
+	return clientImpl
 }
 
-func (c *ClientImpl) Get(url string) ([]byte, error) {
-	logrus.Debugf("Starting to get response for %s", url)
-	httpReq, err := c.generateHTTPRequest(url)
-	if err != nil {
-		return nil, err
+func (c *ClientImpl) Get(ctx context.Context, url string) ([]byte, error) {
+	return get(ctx, c, url)
+}
+
+// Do executes the request until it gets a response that is not worth a retry or the retries are
+// exhausted, only the GET and HEAD requests are retried and hedged. The timeout of the client and the
+// deadline of the context, the earliest one, bound all the attempts.
+func (c *ClientImpl) Do(ctx context.Context, request *Request) (*Response, error) {
+	attributes := []attribute.KeyValue{semconv.HTTPMethodKey.String(request.Method)}
+	if requestURL, err := url.Parse(request.URL); err == nil {
+		attributes = append(attributes, semconv.HTTPURLKey.String(spanURL(requestURL, request.Route)))
+	}
+	if request.Route != "" {
+		attributes = append(attributes, semconv.HTTPRouteKey.String(request.Route))
 	}
+	ctx, span := tracer.Start(ctx, "ClientImpl.Do", trace.WithAttributes(attributes...))
+	response, err := c.do(ctx, request)
+	endSpan(span, response, err)
+	return response, err
+}
 
-	httpResp, err := c.client.Do(httpReq)
-	if err != nil {
-		logrus.Errorf("Error executing GET request for url: %s, err: %s", url, err.Error())
-		return nil, fmt.Errorf("error doing the request, err: %s", err.Error())
+func (c *ClientImpl) do(ctx context.Context, request *Request) (*Response, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debugf("Starting to %s response for %s", request.Method, request.URL)
+	deadline, _ := ctx.Deadline()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		if timeoutDeadline := c.now().Add(c.timeout); deadline.IsZero() || timeoutDeadline.Before(deadline) {
+			deadline = timeoutDeadline
+		}
 	}
 
-	body, err := c.processResponse(httpResp)
+	httpReq, err := c.generateHTTPRequest(ctx, request)
 	if err != nil {
 		return nil, err
 	}
 
-	logrus.Debugf("Finishing to get response %+v with body: %s for url: %s", httpResp,
-		string(body), url)
-	return body, nil
+	for attempt := 1; ; attempt++ {
+		response, retryable, err := c.doHedgedAttempt(httpReq, request)
+		if !retryable || !isIdempotent(request.Method) || attempt >= c.retryPolicy.MaxAttempts ||
+			ctx.Err() != nil {
+			if err == nil {
+				logger.WithField(logging.BodyField, response.loggedBody).Debugf(
+					"Finishing to get response with status code %d for url: %s", response.StatusCode, request.URL)
+				if response.decoded != nil {
+					reflect.ValueOf(request.JSONTarget).Elem().Set(reflect.ValueOf(response.decoded).Elem())
+				}
+			}
+			return response, err
+		}
+
+		wait := c.retryPolicy.backoff(attempt, c.random())
+		if response != nil {
+			if retryAfter, exists := parseRetryAfter(response.Header, c.now()); exists {
+				wait = retryAfter
+			}
+		}
+		if !deadline.IsZero() && c.now().Add(wait).After(deadline) {
+			logger.Errorf("Not retrying request for url: %s, the next attempt would exceed the deadline",
+				request.URL)
+			return response, err
+		}
+
+		logger.Warnf("Retrying request for url: %s in %s, attempt %d failed, %s", request.URL, wait, attempt,
+			describeFailure(response, err))
+		select {
+		case <-c.after(wait):
+		case <-ctx.Done():
+			return response, err
+		}
+	}
 }
 
-func (c *ClientImpl) GetWithUserIdHeader(url, userId string) ([]byte, error) {
-	logrus.Debugf("Starting to get response for %s", url)
-	httpReq, err := c.generateHTTPRequest(url)
+// doHedgedAttempt executes the request, sending a second one when the first is slower than the hedging
+// delay and the budget allows it. The first successful response wins and the other request is canceled.
+func (c *ClientImpl) doHedgedAttempt(httpReq *http.Request, request *Request) (*Response, bool, error) {
+	if !c.hedgingPolicy.Enable || !isIdempotent(request.Method) {
+		return c.doAttempt(httpReq, request)
+	}
+	c.hedgingBudget.earn()
+
+	ctx, cancel := context.WithCancel(httpReq.Context())
+	defer cancel()
+	results := make(chan attemptResult, 2)
+	launch := func() {
+		go func() {
+			response, retryable, err := c.doAttempt(httpReq.WithContext(ctx), request)
+			results <- attemptResult{response: response, retryable: retryable, err: err}
+		}()
+	}
+
+	launch()
+	inFlight := 1
+	hedge := c.after(c.hedgingDelay())
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			if !c.hedgingBudget.spend() {
+				logging.FromContext(ctx).Debugf("Not hedging request for url: %s, the hedging budget is exhausted",
+					httpReq.URL)
+				continue
+			}
+			logging.FromContext(ctx).Debugf("Hedging request for url: %s", httpReq.URL)
+			launch()
+			inFlight++
+		case result := <-results:
+			inFlight--
+			// Wait for the other request, if any, before giving up on this attempt.
+			if (result.err == nil && result.response.IsSuccess()) || inFlight == 0 {
+				return result.response, result.retryable, result.err
+			}
+		}
+	}
+}
+
+func (c *ClientImpl) hedgingDelay() time.Duration {
+	if delay, exists := c.latencies.percentile(c.hedgingPolicy.Percentile); exists {
+		return delay
+	}
+	return c.hedgingPolicy.Delay
+}
+
+// doAttempt executes the request once, reporting whether the response or the error is worth a retry. Every
+// attempt decodes into its own value, the winner is copied into the target of the request at the end.
+func (c *ClientImpl) doAttempt(httpReq *http.Request, request *Request) (*Response, bool, error) {
+	ctx, span := tracer.Start(httpReq.Context(), "HTTP "+httpReq.Method, trace.WithSpanKind(trace.SpanKindClient))
+	// Every attempt has its own span, so it needs its own headers to propagate it. The attributes are taken from
+	// the copy, the hedged attempts run concurrently.
+	httpReq = httpReq.Clone(ctx)
+	span.SetAttributes(requestAttributes(httpReq, request.Route)...)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+	if httpReq.GetBody != nil {
+		// Every attempt needs its own copy of the body, the previous one was consumed.
+		body, err := httpReq.GetBody()
+		if err != nil {
+			err = fmt.Errorf("error building the request, err: %s", err.Error())
+			endSpan(span, nil, err)
+			return nil, false, err
+		}
+		httpReq.Body = body
+	}
+
+	start := c.now()
+	response, err := c.roundTrip(httpReq, request)
+	duration := c.now().Sub(start)
+	if err != nil && isCanceled(ctx) {
+		// The attempt was canceled, like the hedged one that lost, which is neither an error of foaas nor a
+		// failure, so it's not observed.
+		span.AddEvent("canceled")
+		span.End()
+		return nil, false, err
+	}
+	endSpan(span, response, err)
+	if c.observer != nil {
+		c.observer.ObserveAttempt(duration, response, err)
+	}
 	if err != nil {
-		return nil, err
+		// Only the network errors are worth a retry, not the bodies that are too large or invalid.
+		kind := apierror.KindOf(err)
+		return nil, kind != apierror.KindResponseTooLarge && kind != apierror.KindDecode, err
 	}
-	httpReq.Header.Set(constants.UserIDHeader, userId)
 
+	if c.retryPolicy.isRetryableStatusCode(response.StatusCode) {
+		return response, true, nil
+	}
+
+	c.latencies.record(duration)
+	return response, false, nil
+}
+
+// roundTrip sends the request and reads the body of the response.
+func (c *ClientImpl) roundTrip(httpReq *http.Request, request *Request) (*Response, error) {
 	httpResp, err := c.client.Do(httpReq)
 	if err != nil {
-		logrus.Errorf("Error executing GET request for url: %s, err: %s", url, err.Error())
-		return nil, fmt.Errorf("error doing the request, err: %s", err.Error())
+		logAttemptError(httpReq.Context(), "Error executing %s request for url: %s, err: %s", httpReq.Method,
+			httpReq.URL, err.Error())
+		// The url is left out of the error, which is recorded in the spans, since it can have personal data.
+		cause := err
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			cause = urlErr.Err
+		}
+		return nil, upstreamError(fmt.Errorf("error doing the request, err: %s", cause.Error()), err)
 	}
 
-	body, err := c.processResponse(httpResp)
+	response := &Response{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+	}
+	if request.JSONTarget != nil && response.IsSuccess() {
+		response.decoded, response.loggedBody, err = c.decodeBody(httpReq.Context(), httpResp, request)
+	} else {
+		response.Body, err = c.readBody(httpReq.Context(), httpResp)
+		response.loggedBody = describeBody(response.Body, int64(len(response.Body)))
+	}
 	if err != nil {
 		return nil, err
 	}
-
-	logrus.Debugf("Finishing to get response %+v with body: %s for url: %s", httpResp,
-		string(body), url)
-	return body, nil
+	return response, nil
 }
 
-func (c *ClientImpl) generateHTTPRequest(url string) (*http.Request, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *ClientImpl) generateHTTPRequest(ctx context.Context, request *Request) (*http.Request, error) {
+	requestURL, err := buildURL(request)
 	if err != nil {
-		logrus.Errorf("Error creating request for url: %s, err: %s", url, err.Error())
+		logging.FromContext(ctx).Errorf("Error creating request for url: %s, err: %s", request.URL, err.Error())
 		return nil, fmt.Errorf("error building the request, err: %s", err.Error())
 	}
-	req.Header.Add("Accept", "application/json")
+
+	var body io.Reader
+	if request.Body != nil {
+		body = bytes.NewReader(request.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, requestURL, body)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("Error creating request for url: %s, err: %s", request.URL, err.Error())
+		return nil, fmt.Errorf("error building the request, err: %s", err.Error())
+	}
+
+	req.Header.Set("Accept", "application/json")
+	for key, values := range request.Header {
+		req.Header[key] = append([]string(nil), values...)
+	}
 	return req, nil
 }
 
-func (c *ClientImpl) processResponse(httpResp *http.Response) ([]byte, error) {
-	body, err := c.readBody(httpResp)
-	if err != nil {
-		return nil, err
+// buildURL adds the query params of the request to the ones already in its url.
+func buildURL(request *Request) (string, error) {
+	if len(request.Query) == 0 {
+		return request.URL, nil
 	}
 
-	if http.StatusOK != httpResp.StatusCode {
-		logrus.Errorf("Status code (%d) is different than OK", httpResp.StatusCode)
-		return nil, fmt.Errorf("error executing request, status code: %v", httpResp.StatusCode)
+	requestURL, err := url.Parse(request.URL)
+	if err != nil {
+		return "", err
+	}
+	query := requestURL.Query()
+	for key, values := range request.Query {
+		for _, value := range values {
+			query.Add(key, value)
+		}
 	}
+	requestURL.RawQuery = query.Encode()
+	return requestURL.String(), nil
+}
+
+func (c *ClientImpl) readBody(ctx context.Context, httpResp *http.Response) ([]byte, error) {
+	defer closeBody(ctx, httpResp)
 
+	body, err := ioutil.ReadAll(newBoundedReader(httpResp.Body, c.maxResponseSize))
+	if err != nil {
+		logAttemptError(ctx, "Error reading body %v, err: %s", httpResp.Body, err.Error())
+		return nil, bodyError(err)
+	}
 	return body, nil
 }
 
-func (c *ClientImpl) readBody(httpResp *http.Response) ([]byte, error) {
-	body, err := ioutil.ReadAll(httpResp.Body)
+// decodeBody decodes the body while it's read, into a new value of the type of the target of the request,
+// and returns the beginning of the body to log it.
+func (c *ClientImpl) decodeBody(ctx context.Context, httpResp *http.Response, request *Request) (interface{},
+	string, error) {
+	defer closeBody(ctx, httpResp)
+
+	recorder := &bodyRecorder{reader: newBoundedReader(httpResp.Body, c.maxResponseSize)}
+	decoder := json.NewDecoder(recorder)
+	if request.StrictJSON {
+		decoder.DisallowUnknownFields()
+	}
+	decoded := reflect.New(reflect.TypeOf(request.JSONTarget).Elem()).Interface()
+	if err := decoder.Decode(decoded); err != nil {
+		if isCanceled(ctx) {
+			logging.FromContext(ctx).Debugf("Canceling the decoding of the body, err: %s", err.Error())
+			return nil, "", bodyError(err)
+		}
+		logging.FromContext(ctx).WithField(logging.BodyField, recorder.String()).Errorf("Error decoding body, err: %s",
+			err.Error())
+		return nil, "", bodyError(err)
+	}
+	return decoded, recorder.String(), nil
+}
+
+// bodyError classifies an error reading or decoding a body.
+func bodyError(err error) error {
+	var readErr *readError
+	switch {
+	case apierror.KindOf(err) == apierror.KindResponseTooLarge:
+		return err
+	case errors.As(err, &readErr):
+		return upstreamError(fmt.Errorf("error reading the body, err: %s", err.Error()), readErr.err)
+	default:
+		return apierror.New(apierror.KindDecode, fmt.Errorf("error unmarshaling the body, err: %s", err.Error()))
+	}
+}
+
+func closeBody(ctx context.Context, httpResp *http.Response) {
+	// Draining what's left of the body lets the connection be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(httpResp.Body, maxLoggedBodySize))
+	if err := httpResp.Body.Close(); err != nil {
+		logging.FromContext(ctx).Errorf("Error closing body, err: %s", err.Error())
+	}
+}
+
+// get executes a GET request with the client, failing when the status code isn't OK.
+func get(ctx context.Context, client Client, url string) ([]byte, error) {
+	response, err := client.Do(ctx, NewRequest(url))
 	if err != nil {
-		logrus.Errorf("Error reading body %v, err: %s", httpResp.Body, err.Error())
-		return nil, fmt.Errorf("error reading the body, err: %s", err.Error())
+		return nil, err
+	}
+	return processResponse(ctx, response)
+}
+
+func processResponse(ctx context.Context, response *Response) ([]byte, error) {
+	if err := response.StatusError(); err != nil {
+		logging.FromContext(ctx).Errorf("Status code (%d) is different than OK", response.StatusCode)
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// isIdempotent reports whether the request can be sent more than once, by retries or hedging, without
+// repeating its effects on foaas.
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// isCanceled reports whether the context of the attempt was canceled, like the one of a hedged attempt that
+// lost, rather than timed out.
+func isCanceled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// logAttemptError logs the error of an attempt, at debug level when the attempt was canceled.
+func logAttemptError(ctx context.Context, format string, args ...interface{}) {
+	if isCanceled(ctx) {
+		logging.FromContext(ctx).Debugf(format, args...)
+		return
 	}
+	logging.FromContext(ctx).Errorf(format, args...)
+}
 
-	err = httpResp.Body.Close()
+func describeFailure(response *Response, err error) string {
 	if err != nil {
-		logrus.Errorf("Error closing body, err: %s", err.Error())
+		return fmt.Sprintf("err: %s", err.Error())
 	}
-	return body, nil
+	return fmt.Sprintf("status code: %d", response.StatusCode)
+}
+
+// upstreamError classifies a failure to talk to foaas, which is a timeout or a cancellation when the cause is
+// one.
+func upstreamError(err, cause error) error {
+	if apierror.IsTimeout(cause) {
+		return apierror.New(apierror.KindTimeout, err)
+	}
+	if apierror.IsCanceled(cause) {
+		return apierror.New(apierror.KindCanceled, err)
+	}
+	return apierror.New(apierror.KindUpstreamServerError, err)
 }
@@ -2,13 +2,19 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	httpmock "github.com/hortelanobruno/foaas-api/http/mocks"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -23,16 +29,26 @@ func TestReadBody(t *testing.T) {
 		{
 			"Should return an error when readAll method returns an error",
 			func() *http.Response {
-				readCloserMock := &httpmock.ReadCloser{}
+				readCloserMock := &mockReadCloser{}
 				readCloserMock.On("Read", mock.Anything).
 					Return(0, fmt.Errorf("error reading"))
+				readCloserMock.On("Close").Return(nil)
 				httpResp := &http.Response{
 					Body: readCloserMock,
 				}
 				return httpResp
 			}(),
 			nil,
-			fmt.Errorf("error reading the body, err: error reading"),
+			apierror.New(apierror.KindUpstreamServerError, fmt.Errorf("error reading the body, err: error reading")),
+		},
+		{
+			"Should return an error when the body is too large",
+			&http.Response{
+				Body: ioutil.NopCloser(bytes.NewReader([]byte("unit testing a large body"))),
+			},
+			nil,
+			apierror.New(apierror.KindResponseTooLarge,
+				fmt.Errorf("response body exceeds the maximum size of 12 bytes")),
 		},
 		{
 			"Should return a nil error and the body",
@@ -47,10 +63,10 @@ func TestReadBody(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			// Initialization
-			client := NewClientImpl(time.Duration(0))
+			client := NewClientImpl(time.Duration(0), WithMaxResponseSize(12))
 
 			// Operation
-			body, err := client.readBody(c.input)
+			body, err := client.readBody(context.Background(), c.input)
 
 			// Validation
 			assert.EqualValues(t, c.expectedBody, body)
@@ -59,41 +75,61 @@ func TestReadBody(t *testing.T) {
 	}
 }
 
+func TestWithMaxResponseSize(t *testing.T) {
+	cases := []struct {
+		name                    string
+		maxResponseSize         int64
+		expectedMaxResponseSize int64
+	}{
+		{
+			"Should limit the bodies to the size",
+			64,
+			64,
+		},
+		{
+			"Should keep the default limit when the size is zero",
+			0,
+			defaultMaxResponseSize,
+		},
+		{
+			"Should keep the default limit when the size is negative",
+			-1,
+			defaultMaxResponseSize,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			client := NewClientImpl(time.Duration(0), WithMaxResponseSize(c.maxResponseSize))
+
+			// Validation
+			assert.EqualValues(t, c.expectedMaxResponseSize, client.maxResponseSize)
+		})
+	}
+}
+
 func TestProcessResponse(t *testing.T) {
 	cases := []struct {
 		name          string
-		input         *http.Response
+		input         *Response
 		expectedBody  []byte
 		expectedError error
 	}{
-		{
-			"Should return an error when readAll method returns an error",
-			func() *http.Response {
-				readCloserMock := &httpmock.ReadCloser{}
-				readCloserMock.On("Read", mock.Anything).
-					Return(0, fmt.Errorf("error reading"))
-				httpResp := &http.Response{
-					Body: readCloserMock,
-				}
-				return httpResp
-			}(),
-			nil,
-			fmt.Errorf("error reading the body, err: error reading"),
-		},
 		{
 			"Should return an error when status code is different than 200",
-			&http.Response{
-				Body:       ioutil.NopCloser(bytes.NewReader([]byte("unit testing"))),
+			&Response{
 				StatusCode: http.StatusInternalServerError,
+				Body:       []byte("unit testing"),
 			},
 			nil,
-			fmt.Errorf("error executing request, status code: 500"),
+			apierror.NewUpstreamStatus(500, fmt.Errorf("error executing request, status code: 500")),
 		},
 		{
 			"Should return a nil error and the body",
-			&http.Response{
-				Body:       ioutil.NopCloser(bytes.NewReader([]byte("unit testing"))),
+			&Response{
 				StatusCode: http.StatusOK,
+				Body:       []byte("unit testing"),
 			},
 			[]byte("unit testing"),
 			nil,
@@ -102,11 +138,8 @@ func TestProcessResponse(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			// Initialization
-			client := NewClientImpl(time.Duration(0))
-
 			// Operation
-			body, err := client.processResponse(c.input)
+			body, err := processResponse(context.Background(), c.input)
 
 			// Validation
 			assert.EqualValues(t, c.expectedBody, body)
@@ -118,26 +151,48 @@ func TestProcessResponse(t *testing.T) {
 func TestGenerateHTTPRequest(t *testing.T) {
 	cases := []struct {
 		name          string
-		input         string
+		input         *Request
 		expectedReq   *http.Request
 		expectedError error
 	}{
 		{
 			"Should return an error when url is invalid",
-			":",
+			NewRequest(":"),
+			nil,
+			fmt.Errorf(`error building the request, err: parse ":": missing protocol scheme`),
+		},
+		{
+			"Should return an error when url is invalid and has query params",
+			NewRequest(":").WithQueryParam("lang", "en"),
 			nil,
 			fmt.Errorf(`error building the request, err: parse ":": missing protocol scheme`),
 		},
 		{
 			"Should return a nil error",
-			"https://foaas.com/version",
+			NewRequest("https://foaas.com/version"),
 			func() *http.Request {
-				req, _ := http.NewRequest("GET", "https://foaas.com/version", nil)
+				req, _ := http.NewRequestWithContext(context.Background(), "GET", "https://foaas.com/version", nil)
 				req.Header.Set("Accept", "application/json")
 				return req
 			}(),
 			nil,
 		},
+		{
+			"Should return a nil error and add the method, headers and query params",
+			NewRequest("https://foaas.com/version?lang=en").
+				WithMethod(http.MethodHead).
+				WithHeader("Accept", "text/plain").
+				WithHeader("UserId", "123").
+				WithQueryParam("shoutcloud", "true"),
+			func() *http.Request {
+				req, _ := http.NewRequestWithContext(context.Background(), "HEAD",
+					"https://foaas.com/version?lang=en&shoutcloud=true", nil)
+				req.Header.Set("Accept", "text/plain")
+				req.Header.Set("UserId", "123")
+				return req
+			}(),
+			nil,
+		},
 	}
 
 	for _, c := range cases {
@@ -146,7 +201,7 @@ func TestGenerateHTTPRequest(t *testing.T) {
 			client := NewClientImpl(time.Duration(0))
 
 			// Operation
-			req, err := client.generateHTTPRequest(c.input)
+			req, err := client.generateHTTPRequest(context.Background(), c.input)
 
 			// Validation
 			assert.EqualValues(t, c.expectedReq, req)
@@ -189,7 +244,7 @@ func TestGet(t *testing.T) {
 			"Should return a nil error",
 			fmt.Sprintf("%s?status=badRequest", server.URL),
 			nil,
-			fmt.Errorf("error executing request, status code: 400"),
+			apierror.NewUpstreamStatus(400, fmt.Errorf("error executing request, status code: 400")),
 		},
 	}
 
@@ -199,7 +254,7 @@ func TestGet(t *testing.T) {
 			client := NewClientImpl(time.Duration(0))
 
 			// Operation
-			body, err := client.Get(c.url)
+			body, err := client.Get(context.Background(), c.url)
 
 			// Validation
 			assert.EqualValues(t, c.expectedBody, body)
@@ -208,60 +263,682 @@ func TestGet(t *testing.T) {
 	}
 }
 
-func TestGetWithUserIdHeader(t *testing.T) {
+func TestDo(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.RawQuery {
-		case "status=ok":
-			w.WriteHeader(http.StatusOK)
-		case "status=badRequest":
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("status") == "badRequest" {
 			w.WriteHeader(http.StatusBadRequest)
 		}
-		_, _ = fmt.Fprint(w, `{"message": "ok"}`)
+		_, _ = fmt.Fprintf(w, `{"method": %q, "userId": %q, "body": %q}`, r.Method, r.Header.Get("UserId"),
+			string(body))
 	}))
 	defer server.Close()
 
 	cases := []struct {
-		name          string
-		url           string
-		userID        string
-		expectedBody  []byte
-		expectedError error
+		name             string
+		request          *Request
+		expectedResponse *Response
+		expectedError    error
 	}{
 		{
 			"Should return an error when url is invalid",
-			":",
-			"123",
+			NewRequest(":"),
 			nil,
 			fmt.Errorf(`error building the request, err: parse ":": missing protocol scheme`),
 		},
 		{
-			"Should return a nil error",
-			fmt.Sprintf("%s?status=ok", server.URL),
-			"123",
+			"Should return a nil error and the response",
+			NewRequest(server.URL).WithHeader("UserId", "123"),
+			&Response{
+				StatusCode: http.StatusOK,
+				Body:       []byte(`{"method": "GET", "userId": "123", "body": ""}`),
+			},
+			nil,
+		},
+		{
+			"Should return a nil error and the response when sending a body",
+			NewRequest(server.URL).WithMethod(http.MethodPost).WithBody([]byte("unit testing")),
+			&Response{
+				StatusCode: http.StatusOK,
+				Body:       []byte(`{"method": "POST", "userId": "", "body": "unit testing"}`),
+			},
+			nil,
+		},
+		{
+			"Should return a nil error and the response when status code is different than 200",
+			NewRequest(server.URL).WithQueryParam("status", "badRequest"),
+			&Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       []byte(`{"method": "GET", "userId": "", "body": ""}`),
+			},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			client := NewClientImpl(time.Duration(0))
+
+			// Operation
+			response, err := client.Do(context.Background(), c.request)
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+			if c.expectedResponse == nil {
+				assert.Nil(t, response)
+				return
+			}
+			assert.EqualValues(t, c.expectedResponse.StatusCode, response.StatusCode)
+			assert.EqualValues(t, c.expectedResponse.Body, response.Body)
+			assert.EqualValues(t, "application/json", response.Header.Get("Content-Type"))
+		})
+	}
+}
+
+type testMessage struct {
+	Message string `json:"message"`
+}
+
+func TestDoWithJSONTarget(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("body") {
+		case "large":
+			_, _ = fmt.Fprintf(w, `{"message": %q}`, strings.Repeat("a", 100))
+		case "unknown":
+			_, _ = fmt.Fprint(w, `{"message": "unit testing", "extra": true}`)
+		case "invalid":
+			_, _ = fmt.Fprint(w, `{"message": `)
+		case "notFound":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `not found`)
+		default:
+			_, _ = fmt.Fprint(w, `{"message": "unit testing"}`)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name            string
+		body            string
+		strict          bool
+		expectedStatus  int
+		expectedBody    []byte
+		expectedMessage testMessage
+		expectedError   error
+	}{
+		{
+			"Should decode the body into the target",
+			"",
+			true,
+			http.StatusOK,
+			nil,
+			testMessage{Message: "unit testing"},
+			nil,
+		},
+		{
+			"Should return an error when the body is too large",
+			"large",
+			true,
+			0,
+			nil,
+			testMessage{},
+			apierror.New(apierror.KindResponseTooLarge,
+				fmt.Errorf("response body exceeds the maximum size of 64 bytes")),
+		},
+		{
+			"Should return an error when the body has unknown fields and the decoding is strict",
+			"unknown",
+			true,
+			0,
+			nil,
+			testMessage{},
+			apierror.New(apierror.KindDecode,
+				fmt.Errorf(`error unmarshaling the body, err: json: unknown field "extra"`)),
+		},
+		{
+			"Should ignore the unknown fields when the decoding is not strict",
+			"unknown",
+			false,
+			http.StatusOK,
+			nil,
+			testMessage{Message: "unit testing"},
+			nil,
+		},
+		{
+			"Should return an error when the body is not valid",
+			"invalid",
+			true,
+			0,
+			nil,
+			testMessage{},
+			apierror.New(apierror.KindDecode, fmt.Errorf("error unmarshaling the body, err: unexpected EOF")),
+		},
+		{
+			"Should keep the body when the status code is not successful",
+			"notFound",
+			true,
+			http.StatusNotFound,
+			[]byte("not found"),
+			testMessage{},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			client := NewClientImpl(time.Duration(0), WithMaxResponseSize(64),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+			message := testMessage{}
+			atomic.StoreInt32(&requests, 0)
+
+			// Operation
+			response, err := client.Do(context.Background(), NewRequest(server.URL).
+				WithQueryParam("body", c.body).WithJSONTarget(&message, c.strict))
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+			assert.EqualValues(t, c.expectedMessage, message)
+			// Neither the bodies that are too large nor the invalid ones are worth a retry.
+			assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+			if c.expectedError != nil {
+				assert.Nil(t, response)
+				return
+			}
+			assert.EqualValues(t, c.expectedStatus, response.StatusCode)
+			assert.EqualValues(t, c.expectedBody, response.Body)
+		})
+	}
+}
+
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+	mutex sync.Mutex
+}
+
+func (f *fakeClock) install(client *ClientImpl) {
+	client.now = func() time.Time {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		return f.now
+	}
+	client.after = func(wait time.Duration) <-chan time.Time {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		f.waits = append(f.waits, wait)
+		f.now = f.now.Add(wait)
+		ch := make(chan time.Time, 1)
+		ch <- f.now
+		return ch
+	}
+	client.random = func() float64 {
+		return 1
+	}
+}
 
+func TestGetWithRetries(t *testing.T) {
+	retryPolicy := RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          100 * time.Millisecond,
+		MaxBackoff:           time.Second,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+
+	cases := []struct {
+		name             string
+		timeout          time.Duration
+		responses        []int
+		retryAfter       string
+		expectedBody     []byte
+		expectedError    error
+		expectedAttempts int
+		expectedWaits    []time.Duration
+	}{
+		{
+			"Should not retry when the first attempt succeeds",
+			time.Minute,
+			[]int{http.StatusOK},
+			"",
 			[]byte(`{"message": "ok"}`),
 			nil,
+			1,
+			nil,
 		},
 		{
-			"Should return a nil error",
-			fmt.Sprintf("%s?status=badRequest", server.URL),
-			"123",
+			"Should retry with exponential backoff until an attempt succeeds",
+			time.Minute,
+			[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			"",
+			[]byte(`{"message": "ok"}`),
 			nil,
-			fmt.Errorf("error executing request, status code: 400"),
+			3,
+			[]time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			"Should return the last error when attempts are exhausted",
+			time.Minute,
+			[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			"",
+			nil,
+			apierror.NewUpstreamStatus(503, fmt.Errorf("error executing request, status code: 503")),
+			3,
+			[]time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			"Should not retry when status code is not retryable",
+			time.Minute,
+			[]int{http.StatusBadRequest, http.StatusOK},
+			"",
+			nil,
+			apierror.NewUpstreamStatus(400, fmt.Errorf("error executing request, status code: 400")),
+			1,
+			nil,
+		},
+		{
+			"Should wait for the retry after header",
+			time.Minute,
+			[]int{http.StatusTooManyRequests, http.StatusOK},
+			"2",
+			[]byte(`{"message": "ok"}`),
+			nil,
+			2,
+			[]time.Duration{2 * time.Second},
+		},
+		{
+			"Should not retry when the retry after header exceeds the deadline",
+			time.Second,
+			[]int{http.StatusTooManyRequests, http.StatusOK},
+			"2",
+			nil,
+			apierror.NewUpstreamStatus(429, fmt.Errorf("error executing request, status code: 429")),
+			1,
+			nil,
+		},
+		{
+			"Should not retry when the backoff exceeds the deadline",
+			250 * time.Millisecond,
+			[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			"",
+			nil,
+			apierror.NewUpstreamStatus(503, fmt.Errorf("error executing request, status code: 503")),
+			2,
+			[]time.Duration{100 * time.Millisecond},
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			// Initialization
-			client := NewClientImpl(time.Duration(0))
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				statusCode := c.responses[attempts]
+				attempts++
+				if c.retryAfter != "" {
+					w.Header().Set("Retry-After", c.retryAfter)
+				}
+				w.WriteHeader(statusCode)
+				_, _ = fmt.Fprint(w, `{"message": "ok"}`)
+			}))
+			defer server.Close()
+
+			client := NewClientImpl(c.timeout, WithRetryPolicy(retryPolicy))
+			clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+			clock.install(client)
 
 			// Operation
-			body, err := client.GetWithUserIdHeader(c.url, c.userID)
+			body, err := client.Get(context.Background(), server.URL)
 
 			// Validation
 			assert.EqualValues(t, c.expectedBody, body)
 			assert.EqualValues(t, c.expectedError, err)
+			assert.EqualValues(t, c.expectedAttempts, attempts)
+			assert.EqualValues(t, c.expectedWaits, clock.waits)
 		})
 	}
 }
+
+func TestGetShouldRetryNetworkErrors(t *testing.T) {
+	// Initialization
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	client := NewClientImpl(time.Minute, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 2,
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	body, err := client.Get(context.Background(), url)
+
+	// Validation
+	assert.Nil(t, body)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, []time.Duration{100 * time.Millisecond}, clock.waits)
+}
+
+type attemptRecorder struct {
+	statusCodes []int
+	errs        []error
+	mutex       sync.Mutex
+}
+
+func (a *attemptRecorder) ObserveAttempt(_ time.Duration, response *Response, err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	statusCode := 0
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	a.statusCodes = append(a.statusCodes, statusCode)
+	a.errs = append(a.errs, err)
+}
+
+func TestDoShouldNotifyTheAttemptObserverOfEveryAttempt(t *testing.T) {
+	// Initialization
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	recorder := &attemptRecorder{}
+	client := NewClientImpl(time.Minute, WithAttemptObserver(recorder), WithRetryPolicy(RetryPolicy{
+		MaxAttempts:          2,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	response, err := client.Do(context.Background(), NewRequest(server.URL))
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+	assert.EqualValues(t, []int{http.StatusServiceUnavailable, http.StatusOK}, recorder.statusCodes)
+	assert.EqualValues(t, []error{nil, nil}, recorder.errs)
+}
+
+func TestGetShouldStopWhenContextIsCanceled(t *testing.T) {
+	// Initialization
+	requestReceived := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestReceived)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClientImpl(time.Minute, WithRetryPolicy(RetryPolicy{
+		MaxAttempts:          3,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requestReceived
+		cancel()
+	}()
+
+	// Operation
+	start := time.Now()
+	body, err := client.Get(ctx, server.URL)
+
+	// Validation
+	assert.Nil(t, body)
+	assert.EqualValues(t, apierror.New(apierror.KindCanceled,
+		fmt.Errorf("error doing the request, err: context canceled")), err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestGetShouldUseTheContextDeadlineWhenItIsEarlierThanTheTimeout(t *testing.T) {
+	// Initialization
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClientImpl(time.Minute, WithRetryPolicy(RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Second,
+		MaxBackoff:           time.Second,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+	clock := &fakeClock{now: time.Now()}
+	clock.install(client)
+	ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(500*time.Millisecond))
+	defer cancel()
+
+	// Operation
+	body, err := client.Get(ctx, server.URL)
+
+	// Validation
+	assert.Nil(t, body)
+	assert.EqualValues(t, apierror.NewUpstreamStatus(503,
+		fmt.Errorf("error executing request, status code: 503")), err)
+	assert.Empty(t, clock.waits)
+}
+
+func TestGetShouldReturnTheHedgedResponseWhenTheFirstRequestIsSlow(t *testing.T) {
+	// Initialization
+	requests := int32(0)
+	firstRequestCanceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-r.Context().Done()
+			close(firstRequestCanceled)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"message": "hedged"}`)
+	}))
+	defer server.Close()
+
+	client := NewClientImpl(time.Minute, WithHedgingPolicy(HedgingPolicy{
+		Enable:        true,
+		Percentile:    95,
+		Delay:         10 * time.Millisecond,
+		BudgetPercent: 10,
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	body, err := client.Get(context.Background(), server.URL)
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, []byte(`{"message": "hedged"}`), body)
+	assert.EqualValues(t, []time.Duration{10 * time.Millisecond}, clock.waits)
+	select {
+	case <-firstRequestCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request was not canceled")
+	}
+}
+
+func TestGetShouldNotReportTheHedgedRequestThatLostAsAnError(t *testing.T) {
+	// Initialization
+	logs := test.NewGlobal()
+	defer logs.Reset()
+	requests := int32(0)
+	firstRequestCanceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-r.Context().Done()
+			close(firstRequestCanceled)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"message": "hedged"}`)
+	}))
+	defer server.Close()
+
+	recorder := &attemptRecorder{}
+	client := NewClientImpl(time.Minute, WithAttemptObserver(recorder), WithHedgingPolicy(HedgingPolicy{
+		Enable:        true,
+		Percentile:    95,
+		Delay:         10 * time.Millisecond,
+		BudgetPercent: 10,
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	_, err := client.Get(context.Background(), server.URL)
+	<-firstRequestCanceled
+
+	// Validation
+	assert.Nil(t, err)
+	assert.Never(t, func() bool {
+		recorder.mutex.Lock()
+		defer recorder.mutex.Unlock()
+		return len(recorder.statusCodes) != 1
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	assert.EqualValues(t, []int{http.StatusOK}, recorder.statusCodes)
+	for _, entry := range logs.AllEntries() {
+		assert.NotEqual(t, logrus.ErrorLevel, entry.Level, entry.Message)
+	}
+}
+
+func TestGetShouldWaitForTheHedgedRequestWhenTheFirstOneFails(t *testing.T) {
+	// Initialization
+	requests := int32(0)
+	hedgeSent := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-hedgeSent
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		close(hedgeSent)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"message": "hedged"}`)
+	}))
+	defer server.Close()
+
+	client := NewClientImpl(time.Minute, WithHedgingPolicy(HedgingPolicy{
+		Enable:        true,
+		Percentile:    95,
+		Delay:         10 * time.Millisecond,
+		BudgetPercent: 10,
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	body, err := client.Get(context.Background(), server.URL)
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, []byte(`{"message": "hedged"}`), body)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
+
+func TestDoShouldOnlyRetryAndHedgeTheIdempotentRequests(t *testing.T) {
+	cases := []struct {
+		name             string
+		method           string
+		hedgingEnable    bool
+		expectedRequests int32
+	}{
+		{
+			"Should retry a GET request",
+			http.MethodGet,
+			false,
+			3,
+		},
+		{
+			"Should retry a HEAD request",
+			http.MethodHead,
+			false,
+			3,
+		},
+		{
+			"Should not retry a POST request",
+			http.MethodPost,
+			false,
+			1,
+		},
+		{
+			"Should not hedge a POST request",
+			http.MethodPost,
+			true,
+			1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			requests := int32(0)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				time.Sleep(20 * time.Millisecond)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			client := NewClientImpl(time.Minute, WithRetryPolicy(RetryPolicy{
+				MaxAttempts:          3,
+				RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+			}), WithHedgingPolicy(HedgingPolicy{
+				Enable:        c.hedgingEnable,
+				Percentile:    95,
+				Delay:         time.Millisecond,
+				BudgetPercent: 100,
+			}))
+			clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+			clock.install(client)
+
+			// Operation
+			response, err := client.Do(context.Background(), NewRequest(server.URL).WithMethod(c.method).
+				WithBody([]byte("unit testing")))
+
+			// Validation
+			assert.Nil(t, err)
+			assert.EqualValues(t, http.StatusServiceUnavailable, response.StatusCode)
+			assert.EqualValues(t, c.expectedRequests, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestGetShouldNotHedgeWhenTheBudgetIsExhausted(t *testing.T) {
+	// Initialization
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(20 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"message": "ok"}`)
+	}))
+	defer server.Close()
+
+	client := NewClientImpl(time.Minute, WithHedgingPolicy(HedgingPolicy{
+		Enable:        true,
+		Percentile:    95,
+		Delay:         10 * time.Millisecond,
+		BudgetPercent: 0,
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	_, errHedged := client.Get(context.Background(), server.URL)
+	_, errNotHedged := client.Get(context.Background(), server.URL)
+
+	// Validation
+	assert.Nil(t, errHedged)
+	assert.Nil(t, errNotHedged)
+	// The initial token of the budget pays the first hedge, then there is no budget left.
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&requests) == 3
+	}, time.Second, 10*time.Millisecond)
+}
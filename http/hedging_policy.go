@@ -0,0 +1,110 @@
+package http
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	latencySamples       = 100
+	minLatencySamples    = 10
+	maxHedgingBudget     = 10
+	initialHedgingBudget = 1
+)
+
+// HedgingPolicy configures the hedged requests of ClientImpl. When an attempt takes longer than the
+// Percentile of the latencies observed so far, or Delay until there are enough of them, a second
+// request is sent. BudgetPercent caps the hedged requests as a percentage of all the requests.
+type HedgingPolicy struct {
+	Enable        bool
+	Percentile    float64
+	Delay         time.Duration
+	BudgetPercent float64
+}
+
+// latencyTracker keeps the latencies of the last successful attempts to compute their percentiles.
+type latencyTracker struct {
+	latencies []time.Duration
+	next      int
+	mutex     *sync.Mutex
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		latencies: make([]time.Duration, 0, latencySamples),
+		mutex:     &sync.Mutex{},
+	}
+}
+
+func (l *latencyTracker) record(latency time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.latencies) < latencySamples {
+		l.latencies = append(l.latencies, latency)
+		return
+	}
+	l.latencies[l.next] = latency
+	l.next = (l.next + 1) % latencySamples
+}
+
+// percentile returns the latency below which the given percentage of the attempts finished, and false
+// while there are not enough samples to trust it.
+func (l *latencyTracker) percentile(percentile float64) (time.Duration, bool) {
+	l.mutex.Lock()
+	latencies := make([]time.Duration, len(l.latencies))
+	copy(latencies, l.latencies)
+	l.mutex.Unlock()
+
+	if len(latencies) < minLatencySamples {
+		return 0, false
+	}
+
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	index := int(math.Ceil(percentile/100*float64(len(latencies)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(latencies) {
+		index = len(latencies) - 1
+	}
+	return latencies[index], true
+}
+
+// hedgingBudget is a token bucket: every request earns a fraction of a token and every hedged request
+// spends a whole one, so the extra load stays around the configured percentage.
+type hedgingBudget struct {
+	ratio  float64
+	tokens float64
+	mutex  *sync.Mutex
+}
+
+func newHedgingBudget(budgetPercent float64) *hedgingBudget {
+	return &hedgingBudget{
+		ratio:  budgetPercent / 100,
+		tokens: initialHedgingBudget,
+		mutex:  &sync.Mutex{},
+	}
+}
+
+func (h *hedgingBudget) earn() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.tokens = math.Min(h.tokens+h.ratio, maxHedgingBudget)
+}
+
+func (h *hedgingBudget) spend() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
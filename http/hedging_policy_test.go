@@ -0,0 +1,107 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLatencyTrackerPercentile(t *testing.T) {
+	cases := []struct {
+		name              string
+		latencies         []time.Duration
+		percentile        float64
+		expectedLatency   time.Duration
+		expectedAvailable bool
+	}{
+		{
+			"Should return false when there are not enough samples",
+			[]time.Duration{time.Millisecond, 2 * time.Millisecond},
+			50,
+			0,
+			false,
+		},
+		{
+			"Should return the median",
+			millisecondsRange(1, 10),
+			50,
+			5 * time.Millisecond,
+			true,
+		},
+		{
+			"Should return the 95th percentile",
+			millisecondsRange(1, 100),
+			95,
+			95 * time.Millisecond,
+			true,
+		},
+		{
+			"Should keep only the last samples",
+			append(millisecondsRange(1000, 1099), millisecondsRange(1, 100)...),
+			100,
+			100 * time.Millisecond,
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			tracker := newLatencyTracker()
+			for _, latency := range c.latencies {
+				tracker.record(latency)
+			}
+
+			// Operation
+			latency, available := tracker.percentile(c.percentile)
+
+			// Validation
+			assert.EqualValues(t, c.expectedLatency, latency)
+			assert.EqualValues(t, c.expectedAvailable, available)
+		})
+	}
+}
+
+func TestHedgingBudget(t *testing.T) {
+	// Initialization
+	budget := newHedgingBudget(50)
+
+	// Operation
+	spentInitialToken := budget.spend()
+	spentWithoutTokens := budget.spend()
+	budget.earn()
+	spentWithHalfToken := budget.spend()
+	budget.earn()
+	spentWithOneToken := budget.spend()
+
+	// Validation
+	assert.True(t, spentInitialToken)
+	assert.False(t, spentWithoutTokens)
+	assert.False(t, spentWithHalfToken)
+	assert.True(t, spentWithOneToken)
+}
+
+func TestHedgingBudgetShouldBeCapped(t *testing.T) {
+	// Initialization
+	budget := newHedgingBudget(100)
+	for i := 0; i < 100; i++ {
+		budget.earn()
+	}
+
+	// Operation
+	spent := 0
+	for budget.spend() {
+		spent++
+	}
+
+	// Validation
+	assert.EqualValues(t, maxHedgingBudget, spent)
+}
+
+func millisecondsRange(from, to int) []time.Duration {
+	latencies := make([]time.Duration, 0)
+	for i := from; i <= to; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	return latencies
+}
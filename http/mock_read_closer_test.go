@@ -1,16 +1,16 @@
 // Code generated by mockery v0.0.0-dev. DO NOT EDIT.
 
-package mocks
+package http
 
 import mock "github.com/stretchr/testify/mock"
 
-// ReadCloser is an autogenerated mock type for the ReadCloser type
-type ReadCloser struct {
+// mockReadCloser is an autogenerated mock type for the ReadCloser type
+type mockReadCloser struct {
 	mock.Mock
 }
 
 // Close provides a mock function with given fields:
-func (_m *ReadCloser) Close() error {
+func (_m *mockReadCloser) Close() error {
 	ret := _m.Called()
 
 	var r0 error
@@ -24,7 +24,7 @@ func (_m *ReadCloser) Close() error {
 }
 
 // Read provides a mock function with given fields: p
-func (_m *ReadCloser) Read(p []byte) (int, error) {
+func (_m *mockReadCloser) Read(p []byte) (int, error) {
 	ret := _m.Called(p)
 
 	var r0 int
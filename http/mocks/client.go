@@ -2,20 +2,49 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	http "github.com/hortelanobruno/foaas-api/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Client is an autogenerated mock type for the Client type
 type Client struct {
 	mock.Mock
 }
 
-// Get provides a mock function with given fields: url
-func (_m *Client) Get(url string) ([]byte, error) {
-	ret := _m.Called(url)
+// Do provides a mock function with given fields: ctx, request
+func (_m *Client) Do(ctx context.Context, request *http.Request) (*http.Response, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 *http.Response
+	if rf, ok := ret.Get(0).(func(context.Context, *http.Request) *http.Response); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *http.Request) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, url
+func (_m *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	ret := _m.Called(ctx, url)
 
 	var r0 []byte
-	if rf, ok := ret.Get(0).(func(string) []byte); ok {
-		r0 = rf(url)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []byte); ok {
+		r0 = rf(ctx, url)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
@@ -23,8 +52,8 @@ func (_m *Client) Get(url string) ([]byte, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(url)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, url)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Probe checks that foaas is reachable, which is the case when it responds without a server error. The
+// result is cached for the ttl so that frequent readiness checks don't flood foaas, and concurrent checks
+// share a single request. A check whose caller goes away before it finishes is not cached, since it says
+// nothing about foaas.
+type Probe struct {
+	client    Client
+	url       string
+	ttl       time.Duration
+	timeout   time.Duration
+	checkedAt time.Time
+	err       error
+	mutex     *sync.Mutex
+	now       func() time.Time
+}
+
+func NewProbe(client Client, url string, ttl, timeout time.Duration) *Probe {
+	return &Probe{
+		client:  client,
+		url:     url,
+		ttl:     ttl,
+		timeout: timeout,
+		mutex:   &sync.Mutex{},
+		now:     time.Now,
+	}
+}
+
+func (p *Probe) Check(ctx context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.checkedAt.IsZero() && p.now().Sub(p.checkedAt) < p.ttl {
+		return p.err
+	}
+
+	probeCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		probeCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	var checkErr error
+	response, err := p.client.Do(probeCtx, NewRequest(p.url))
+	switch {
+	case err != nil:
+		checkErr = fmt.Errorf("foaas is not reachable, err: %s", err.Error())
+	case response.StatusCode >= http.StatusInternalServerError:
+		checkErr = fmt.Errorf("foaas is failing, status code: %d", response.StatusCode)
+	}
+	if ctx.Err() != nil {
+		return checkErr
+	}
+	p.err = checkErr
+	p.checkedAt = p.now()
+	return p.err
+}
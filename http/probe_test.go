@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const probeURL = "https://foaas.com/version"
+
+func TestProbeCheck(t *testing.T) {
+	cases := []struct {
+		name          string
+		response      *Response
+		err           error
+		expectedError error
+	}{
+		{
+			"Should return a nil error when foaas responds",
+			&Response{StatusCode: http.StatusOK},
+			nil,
+			nil,
+		},
+		{
+			"Should return a nil error when foaas responds with a client error",
+			&Response{StatusCode: http.StatusNotFound},
+			nil,
+			nil,
+		},
+		{
+			"Should return an error when foaas responds with a server error",
+			&Response{StatusCode: http.StatusServiceUnavailable},
+			nil,
+			fmt.Errorf("foaas is failing, status code: 503"),
+		},
+		{
+			"Should return an error when foaas is not reachable",
+			nil,
+			fmt.Errorf("error doing the request"),
+			fmt.Errorf("foaas is not reachable, err: error doing the request"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			client := &mockClient{}
+			client.On("Do", mock.Anything, NewRequest(probeURL)).Return(c.response, c.err)
+			probe := NewProbe(client, probeURL, time.Minute, time.Second)
+
+			// Operation
+			err := probe.Check(context.Background())
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
+
+func TestProbeShouldCacheTheResultForTheTTL(t *testing.T) {
+	// Initialization
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+	client := &mockClient{}
+	client.On("Do", mock.Anything, NewRequest(probeURL)).Return(nil, fmt.Errorf("error doing the request")).Once()
+	client.On("Do", mock.Anything, NewRequest(probeURL)).Return(&Response{StatusCode: http.StatusOK}, nil).Once()
+	probe := NewProbe(client, probeURL, 10*time.Second, time.Second)
+	probe.now = func() time.Time {
+		return now
+	}
+
+	// Operation
+	errCheck1 := probe.Check(context.Background())
+	now = now.Add(5 * time.Second)
+	errCheck2 := probe.Check(context.Background())
+	now = now.Add(5 * time.Second)
+	errCheck3 := probe.Check(context.Background())
+
+	// Validation
+	assert.NotNil(t, errCheck1)
+	assert.EqualValues(t, errCheck1, errCheck2)
+	assert.Nil(t, errCheck3)
+	client.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestProbeShouldNotCacheTheResultWhenTheCallerIsGone(t *testing.T) {
+	// Initialization
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &mockClient{}
+	client.On("Do", mock.Anything, NewRequest(probeURL)).Run(func(mock.Arguments) { cancel() }).
+		Return(nil, context.Canceled).Once()
+	client.On("Do", mock.Anything, NewRequest(probeURL)).Return(&Response{StatusCode: http.StatusOK}, nil).Once()
+	probe := NewProbe(client, probeURL, time.Minute, time.Second)
+
+	// Operation
+	errCanceledCheck := probe.Check(ctx)
+	errCheck := probe.Check(context.Background())
+
+	// Validation
+	assert.EqualValues(t, fmt.Errorf("foaas is not reachable, err: context canceled"), errCanceledCheck)
+	assert.Nil(t, errCheck)
+	client.AssertNumberOfCalls(t, "Do", 2)
+}
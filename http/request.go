@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Request is built with NewRequest and the With methods, which can be chained:
+//
+//	NewRequest(url).WithHeader("UserId", "123").WithQueryParam("lang", "en")
+type Request struct {
+	Method     string
+	URL        string
+	Route      string
+	Header     http.Header
+	Query      url.Values
+	Body       []byte
+	JSONTarget interface{}
+	StrictJSON bool
+}
+
+// NewRequest returns a GET request for the rawURL.
+func NewRequest(rawURL string) *Request {
+	return &Request{
+		Method: http.MethodGet,
+		URL:    rawURL,
+		Header: http.Header{},
+		Query:  url.Values{},
+	}
+}
+
+func (r *Request) WithMethod(method string) *Request {
+	r.Method = method
+	return r
+}
+
+// WithRoute sets the template of the path of the url, like /:operation/:userID, which the traces record
+// instead of the path, since it can have personal data.
+func (r *Request) WithRoute(route string) *Request {
+	r.Route = route
+	return r
+}
+
+func (r *Request) WithHeader(key, value string) *Request {
+	r.Header.Set(key, value)
+	return r
+}
+
+func (r *Request) WithQueryParam(key, value string) *Request {
+	r.Query.Add(key, value)
+	return r
+}
+
+func (r *Request) WithBody(body []byte) *Request {
+	r.Body = body
+	return r
+}
+
+// WithJSONTarget decodes the body of a successful response straight into the target, which must be a
+// pointer, instead of keeping it in the response. A strict decoding fails on unknown fields.
+func (r *Request) WithJSONTarget(target interface{}, strict bool) *Request {
+	r.JSONTarget = target
+	r.StrictJSON = strict
+	return r
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"net/http"
+)
+
+// Response has no Body when the request had a JSONTarget and the response was successful.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	decoded    interface{}
+	loggedBody string
+}
+
+func (r *Response) IsSuccess() bool {
+	return r.StatusCode >= http.StatusOK && r.StatusCode < http.StatusMultipleChoices
+}
+
+// Decode unmarshals the JSON body of the response into the target.
+func (r *Response) Decode(target interface{}) error {
+	if err := json.Unmarshal(r.Body, target); err != nil {
+		return fmt.Errorf("error unmarshaling the body, err: %s", err.Error())
+	}
+	return nil
+}
+
+// StatusError returns the error of a response whose status code isn't OK.
+func (r *Response) StatusError() error {
+	if http.StatusOK != r.StatusCode {
+		return apierror.NewUpstreamStatus(r.StatusCode,
+			fmt.Errorf("error executing request, status code: %v", r.StatusCode))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how ClientImpl retries a failed request. Only network errors and the
+// RetryableStatusCodes are retried, up to MaxAttempts attempts in total.
+type RetryPolicy struct {
+	MaxAttempts          int
+	BaseBackoff          time.Duration
+	MaxBackoff           time.Duration
+	RetryableStatusCodes []int
+}
+
+// NoRetryPolicy makes a single attempt per request.
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 1,
+	}
+}
+
+func (r RetryPolicy) isRetryableStatusCode(statusCode int) bool {
+	for _, retryableStatusCode := range r.RetryableStatusCodes {
+		if retryableStatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the time to wait after the given failed attempt, starting at 1, using full jitter:
+// a random duration between zero and the exponential backoff, capped by MaxBackoff.
+func (r RetryPolicy) backoff(attempt int, random float64) time.Duration {
+	ceiling := r.BaseBackoff
+	for i := 1; i < attempt && ceiling < r.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+	if ceiling > r.MaxBackoff {
+		ceiling = r.MaxBackoff
+	}
+	return time.Duration(random * float64(ceiling))
+}
+
+// parseRetryAfter reads the Retry-After header, that can be a number of seconds or an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	retryPolicy := RetryPolicy{
+		MaxAttempts: 10,
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+
+	cases := []struct {
+		name            string
+		attempt         int
+		random          float64
+		expectedBackoff time.Duration
+	}{
+		{
+			"Should return the base backoff after the first attempt",
+			1,
+			1,
+			100 * time.Millisecond,
+		},
+		{
+			"Should double the backoff after each attempt",
+			3,
+			1,
+			400 * time.Millisecond,
+		},
+		{
+			"Should cap the backoff with the max backoff",
+			20,
+			1,
+			time.Second,
+		},
+		{
+			"Should apply the jitter",
+			3,
+			0.25,
+			100 * time.Millisecond,
+		},
+		{
+			"Should return zero when the jitter is zero",
+			3,
+			0,
+			0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			backoff := retryPolicy.backoff(c.attempt, c.random)
+
+			// Validation
+			assert.EqualValues(t, c.expectedBackoff, backoff)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)
+
+	cases := []struct {
+		name           string
+		retryAfter     string
+		expectedWait   time.Duration
+		expectedExists bool
+	}{
+		{
+			"Should return false when header is missing",
+			"",
+			0,
+			false,
+		},
+		{
+			"Should return the seconds",
+			"3",
+			3 * time.Second,
+			true,
+		},
+		{
+			"Should return false when seconds are negative",
+			"-3",
+			0,
+			false,
+		},
+		{
+			"Should return the time until the date",
+			"Wed, 30 Mar 2022 00:00:05 GMT",
+			5 * time.Second,
+			true,
+		},
+		{
+			"Should return zero when the date is in the past",
+			"Tue, 29 Mar 2022 00:00:05 GMT",
+			0,
+			true,
+		},
+		{
+			"Should return false when header is invalid",
+			"tomorrow",
+			0,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			header := http.Header{}
+			header.Set("Retry-After", c.retryAfter)
+
+			// Operation
+			wait, exists := parseRetryAfter(header, now)
+
+			// Validation
+			assert.EqualValues(t, c.expectedWait, wait)
+			assert.EqualValues(t, c.expectedExists, exists)
+		})
+	}
+}
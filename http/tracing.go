@@ -0,0 +1,55 @@
+package http
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/url"
+)
+
+var tracer = otel.Tracer("github.com/hortelanobruno/foaas-api/http")
+
+// endSpan records the status code of the response or the error in the span, and ends it.
+func endSpan(span trace.Span, response *Response, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(response.StatusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(response.StatusCode, trace.SpanKindClient))
+}
+
+// requestAttributes are the attributes of the request, where the url has the route of the request instead of
+// its path, see Request.WithRoute.
+func requestAttributes(httpReq *http.Request, route string) []attribute.KeyValue {
+	attributes := semconv.HTTPClientAttributesFromHTTPRequest(httpReq)
+	for i, attribute := range attributes {
+		if attribute.Key == semconv.HTTPURLKey {
+			attributes[i] = semconv.HTTPURLKey.String(spanURL(httpReq.URL, route))
+		}
+	}
+	if route != "" {
+		attributes = append(attributes, semconv.HTTPRouteKey.String(route))
+	}
+	return attributes
+}
+
+// spanURL returns the url without its credentials and, when there's a route, with the route as its path and
+// without its query.
+func spanURL(requestURL *url.URL, route string) string {
+	spanURL := *requestURL
+	spanURL.User = nil
+	if route != "" {
+		spanURL.Path = route
+		spanURL.RawPath = ""
+		spanURL.RawQuery = ""
+		spanURL.Fragment = ""
+	}
+	return spanURL.String()
+}
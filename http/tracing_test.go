@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDoShouldTraceEveryAttemptAndPropagateTheTraceContext(t *testing.T) {
+	// Initialization
+	exporter := testutil.RecordSpans()
+
+	var mutex sync.Mutex
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientImpl(time.Minute, WithRetryPolicy(RetryPolicy{
+		MaxAttempts:          2,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+	clock := &fakeClock{now: time.Date(2022, time.March, 30, 0, 0, 0, 00, time.UTC)}
+	clock.install(client)
+
+	// Operation
+	response, err := client.Do(context.Background(), NewRequest(server.URL))
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	firstAttempt, secondAttempt, do := spans[0], spans[1], spans[2]
+	assert.EqualValues(t, "ClientImpl.Do", do.Name)
+	assert.False(t, do.Parent.IsValid())
+	assert.Contains(t, do.Attributes, semconv.HTTPStatusCodeKey.Int(http.StatusOK))
+	for i, attempt := range []tracetest.SpanStub{firstAttempt, secondAttempt} {
+		assert.EqualValues(t, "HTTP GET", attempt.Name)
+		assert.EqualValues(t, trace.SpanKindClient, attempt.SpanKind)
+		assert.EqualValues(t, do.SpanContext.SpanID(), attempt.Parent.SpanID())
+		assert.EqualValues(t, fmt.Sprintf("00-%s-%s-01", attempt.SpanContext.TraceID(),
+			attempt.SpanContext.SpanID()), traceparents[i])
+	}
+	assert.EqualValues(t, codes.Error, firstAttempt.Status.Code)
+	assert.Contains(t, firstAttempt.Attributes, semconv.HTTPStatusCodeKey.Int(http.StatusServiceUnavailable))
+	assert.EqualValues(t, codes.Unset, secondAttempt.Status.Code)
+}
+
+func TestDoShouldTraceTheRouteInsteadOfThePathOfTheURL(t *testing.T) {
+	cases := []struct {
+		name               string
+		closeServer        bool
+		expectedStatusCode codes.Code
+	}{
+		{
+			"Should trace the route when the request succeeds",
+			false,
+			codes.Unset,
+		},
+		{
+			"Should trace the route when the request fails",
+			true,
+			codes.Error,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			exporter := testutil.RecordSpans()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+			if c.closeServer {
+				server.Close()
+			}
+			client := NewClientImpl(time.Minute)
+
+			// Operation
+			_, _ = client.Do(context.Background(), NewRequest(server.URL+"/asshole/john.doe%40mail.com?a=b").
+				WithRoute("/:operation/:userID"))
+
+			// Validation
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 2)
+			for _, span := range spans {
+				assert.EqualValues(t, c.expectedStatusCode, span.Status.Code)
+				assert.Contains(t, span.Attributes, semconv.HTTPURLKey.String(server.URL+"/:operation/:userID"))
+				assert.Contains(t, span.Attributes, semconv.HTTPRouteKey.String("/:operation/:userID"))
+				assert.NotContains(t, fmt.Sprintf("%v %v %v", span.Attributes, span.Events, span.Status), "john.doe")
+			}
+		})
+	}
+}
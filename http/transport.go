@@ -0,0 +1,207 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TransportConfig configures how ClientImpl connects to foaas. The zero value keeps the settings of the
+// default transport, where the proxy is taken from the environment.
+type TransportConfig struct {
+	ProxyURL              string
+	CABundleFile          string
+	ClientCertFile        string
+	ClientKeyFile         string
+	TLSMinVersion         string
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	DisableHTTP2          bool
+	DisableKeepAlives     bool
+}
+
+// PoolStats is a snapshot of the connections of a Transport.
+type PoolStats struct {
+	OpenConnections   int64
+	OpenedConnections uint64
+	ReusedConnections uint64
+}
+
+// Transport is an http.RoundTripper that keeps the statistics of its connection pool.
+type Transport struct {
+	// The counters go first to be 64-bit aligned, as atomic needs.
+	openConnections   int64
+	openedConnections uint64
+	reusedConnections uint64
+	transport         *http.Transport
+}
+
+// NewTransport builds a transport from the default one with the given configuration.
+func NewTransport(config TransportConfig) (*Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	pooledTransport := &Transport{
+		transport: transport,
+	}
+	configurePool(transport, config)
+	transport.DialContext = pooledTransport.dialContext(config)
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("proxy url %q is not valid, err: %s", config.ProxyURL, err.Error())
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if config.CABundleFile != "" {
+		rootCAs, err := loadCABundle(config.CABundleFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig.RootCAs = rootCAs
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the client certificate, err: %s", err.Error())
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.TLSMinVersion != "" {
+		version, exists := tlsVersions[config.TLSMinVersion]
+		if !exists {
+			return nil, fmt.Errorf("TLS version %q is not supported", config.TLSMinVersion)
+		}
+		transport.TLSClientConfig.MinVersion = version
+	}
+
+	return pooledTransport, nil
+}
+
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddUint64(&t.reusedConnections, 1)
+			}
+		},
+	}
+	return t.transport.RoundTrip(request.WithContext(httptrace.WithClientTrace(request.Context(), trace)))
+}
+
+func (t *Transport) CloseIdleConnections() {
+	t.transport.CloseIdleConnections()
+}
+
+func (t *Transport) Stats() PoolStats {
+	return PoolStats{
+		OpenConnections:   atomic.LoadInt64(&t.openConnections),
+		OpenedConnections: atomic.LoadUint64(&t.openedConnections),
+		ReusedConnections: atomic.LoadUint64(&t.reusedConnections),
+	}
+}
+
+// dialContext dials like the default transport, counting the connections until they are closed.
+func (t *Transport) dialContext(config TransportConfig) func(context.Context, string, string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if config.DialTimeout > 0 {
+		dialer.Timeout = config.DialTimeout
+	}
+	if config.KeepAlive > 0 {
+		dialer.KeepAlive = config.KeepAlive
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		atomic.AddInt64(&t.openConnections, 1)
+		atomic.AddUint64(&t.openedConnections, 1)
+		return &trackedConn{Conn: conn, onClose: func() {
+			atomic.AddInt64(&t.openConnections, -1)
+		}}, nil
+	}
+}
+
+// trackedConn calls onClose the first time it's closed.
+type trackedConn struct {
+	net.Conn
+	onClose func()
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
+
+func configurePool(transport *http.Transport, config TransportConfig) {
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+	if config.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+	if config.DisableHTTP2 {
+		// A non-nil empty map is the documented way to turn HTTP/2 off.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	transport.DisableKeepAlives = config.DisableKeepAlives
+}
+
+// loadCABundle adds the certificates of the bundle to the ones of the system.
+func loadCABundle(file string) (*x509.CertPool, error) {
+	bundle, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the CA bundle, err: %s", err.Error())
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("CA bundle %s has no certificates", file)
+	}
+	return rootCAs, nil
+}
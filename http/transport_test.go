@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := testutil.WriteCertificate(t, dir, "client", 1)
+	emptyFile := filepath.Join(dir, "empty.pem")
+	assert.Nil(t, ioutil.WriteFile(emptyFile, []byte("no certificates"), 0600))
+
+	cases := []struct {
+		name          string
+		config        TransportConfig
+		expectedError error
+	}{
+		{
+			"Should return an error when the proxy url is invalid",
+			TransportConfig{ProxyURL: "http://[::1"},
+			fmt.Errorf(`proxy url "http://[::1" is not valid, err: parse "http://[::1": missing ']' in host`),
+		},
+		{
+			"Should return an error when the CA bundle doesn't exist",
+			TransportConfig{CABundleFile: filepath.Join(dir, "missing.pem")},
+			fmt.Errorf("error reading the CA bundle, err: open %s: no such file or directory",
+				filepath.Join(dir, "missing.pem")),
+		},
+		{
+			"Should return an error when the CA bundle has no certificates",
+			TransportConfig{CABundleFile: emptyFile},
+			fmt.Errorf("CA bundle %s has no certificates", emptyFile),
+		},
+		{
+			"Should return an error when the client certificate has no key",
+			TransportConfig{ClientCertFile: certFile},
+			fmt.Errorf("error loading the client certificate, err: open : no such file or directory"),
+		},
+		{
+			"Should return an error when the TLS version is not supported",
+			TransportConfig{TLSMinVersion: "1.4"},
+			fmt.Errorf(`TLS version "1.4" is not supported`),
+		},
+		{
+			"Should return a nil error when the configuration is empty",
+			TransportConfig{},
+			nil,
+		},
+		{
+			"Should return a nil error when the configuration is valid",
+			TransportConfig{
+				ProxyURL:       "http://proxy:3128",
+				CABundleFile:   certFile,
+				ClientCertFile: certFile,
+				ClientKeyFile:  keyFile,
+				TLSMinVersion:  "1.3",
+			},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			transport, err := NewTransport(c.config)
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+			if c.expectedError != nil {
+				assert.Nil(t, transport)
+			} else {
+				assert.NotNil(t, transport)
+			}
+		})
+	}
+}
+
+func TestNewTransportShouldApplyTheConfiguration(t *testing.T) {
+	// Initialization
+	certFile, keyFile := testutil.WriteCertificate(t, t.TempDir(), "client", 1)
+	request, _ := http.NewRequest("GET", "https://foaas.com/version", nil)
+
+	// Operation
+	transport, err := NewTransport(TransportConfig{
+		ProxyURL:       "http://proxy:3128",
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+		TLSMinVersion:  "1.3",
+	})
+
+	// Validation
+	assert.Nil(t, err)
+	proxyURL, _ := transport.transport.Proxy(request)
+	assert.EqualValues(t, "http://proxy:3128", proxyURL.String())
+	assert.Len(t, transport.transport.TLSClientConfig.Certificates, 1)
+	assert.EqualValues(t, tls.VersionTLS13, transport.transport.TLSClientConfig.MinVersion)
+}
+
+func TestGetWithCustomCAAndClientCertificate(t *testing.T) {
+	// Initialization
+	dir := t.TempDir()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"clientCertificates": %d}`, len(r.TLS.PeerCertificates))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caBundleFile := filepath.Join(dir, "ca.pem")
+	assert.Nil(t, ioutil.WriteFile(caBundleFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE",
+		Bytes: server.Certificate().Raw}), 0600))
+	certFile, keyFile := testutil.WriteCertificate(t, dir, "client", 1)
+	transport, err := NewTransport(TransportConfig{
+		CABundleFile:   caBundleFile,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	})
+	assert.Nil(t, err)
+	client := NewClientImpl(time.Minute, WithTransport(transport))
+
+	// Operation
+	body, err := client.Get(context.Background(), server.URL)
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, []byte(`{"clientCertificates": 1}`), body)
+}
+
+func TestNewTransportShouldConfigureThePool(t *testing.T) {
+	// Operation
+	transport, err := NewTransport(TransportConfig{
+		MaxIdleConns:          50,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       time.Minute,
+		TLSHandshakeTimeout:   2 * time.Second,
+		ResponseHeaderTimeout: 3 * time.Second,
+		DisableHTTP2:          true,
+		DisableKeepAlives:     true,
+	})
+
+	// Validation
+	assert.Nil(t, err)
+	assert.EqualValues(t, 50, transport.transport.MaxIdleConns)
+	assert.EqualValues(t, 20, transport.transport.MaxIdleConnsPerHost)
+	assert.EqualValues(t, time.Minute, transport.transport.IdleConnTimeout)
+	assert.EqualValues(t, 2*time.Second, transport.transport.TLSHandshakeTimeout)
+	assert.EqualValues(t, 3*time.Second, transport.transport.ResponseHeaderTimeout)
+	assert.False(t, transport.transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.transport.TLSNextProto)
+	assert.True(t, transport.transport.DisableKeepAlives)
+}
+
+func TestTransportStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"message": "ok"}`)
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name          string
+		config        TransportConfig
+		expectedStats PoolStats
+	}{
+		{
+			"Should reuse the connection when keep-alives are enabled",
+			TransportConfig{},
+			PoolStats{OpenConnections: 1, OpenedConnections: 1, ReusedConnections: 2},
+		},
+		{
+			"Should open a connection per request when keep-alives are disabled",
+			TransportConfig{DisableKeepAlives: true},
+			PoolStats{OpenConnections: 0, OpenedConnections: 3, ReusedConnections: 0},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			transport, err := NewTransport(c.config)
+			assert.Nil(t, err)
+			client := NewClientImpl(time.Minute, WithTransport(transport))
+
+			// Operation
+			for i := 0; i < 3; i++ {
+				_, err := client.Get(context.Background(), server.URL)
+				assert.Nil(t, err)
+			}
+
+			// Validation
+			assert.Eventually(t, func() bool {
+				return transport.Stats() == c.expectedStats
+			}, time.Second, 10*time.Millisecond, "stats: %+v", transport.Stats())
+			transport.CloseIdleConnections()
+			assert.Eventually(t, func() bool {
+				return transport.Stats().OpenConnections == 0
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
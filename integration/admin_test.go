@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/admin"
+	"github.com/hortelanobruno/foaas-api/cmd/server"
+	"github.com/hortelanobruno/foaas-api/domain/service"
+	"github.com/hortelanobruno/foaas-api/domain/service/handler"
+	"github.com/hortelanobruno/foaas-api/domain/validator"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIntegrationShouldServeTheAdminApartFromThePublicEndpoints(t *testing.T) {
+	// Initialization
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), nil)
+	adminHandler := admin.NewHandler("secret", func(writer io.Writer) error {
+		_, err := fmt.Fprint(writer, "listen: :4000\n")
+		return err
+	}, nil)
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), nil,
+		server.WithAdmin("127.0.0.1:0", adminHandler)))
+	defer shutdownServer(t, server)
+
+	// Operation
+	adminResponse := requestWithToken(t, fmt.Sprintf("http://%s/debug/pprof/", server.AdminAddr()), "secret")
+	deniedResponse := requestWithToken(t, fmt.Sprintf("http://%s/debug/pprof/", server.AdminAddr()), "")
+	publicResponse := requestWithToken(t, fmt.Sprintf("http://%s/debug/pprof/", server.Addr()), "secret")
+
+	// Validation
+	assert.EqualValues(t, http.StatusOK, adminResponse.StatusCode)
+	assert.EqualValues(t, http.StatusUnauthorized, deniedResponse.StatusCode)
+	assert.EqualValues(t, http.StatusNotFound, publicResponse.StatusCode)
+}
+
+func TestIntegrationShouldShutDownTheAdminWhenTheRequestsCantBeDrained(t *testing.T) {
+	// Initialization
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	foaasServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+	}))
+	defer foaasServer.Close()
+	defer close(release)
+
+	foaasURL, _ := url.Parse(foaasServer.URL)
+	httpClient := customhttp.NewClientImpl(5 * time.Second)
+	messageService := service.NewMessageServiceImpl(foaasURL, httpClient,
+		validator.NewResponseValidatorImpl(1000, 200))
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), messageService)
+	adminHandler := admin.NewHandler("secret", func(writer io.Writer) error { return nil }, nil)
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), nil,
+		server.WithAdmin("127.0.0.1:0", adminHandler)))
+	go func() {
+		_, _ = requestMessageForUser(httpClient, fmt.Sprintf("http://%s/message", server.Addr()), "123")
+	}()
+	<-requested
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Operation
+	err := server.Shutdown(ctx)
+
+	// Validation
+	assert.EqualError(t, err, "error draining the requests, err: context deadline exceeded")
+	_, adminErr := http.Get(fmt.Sprintf("http://%s/debug/pprof/", server.AdminAddr()))
+	assert.NotNil(t, adminErr)
+}
+
+func requestWithToken(t *testing.T, url, token string) *http.Response {
+	request, _ := http.NewRequest("GET", url, nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	return response
+}
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/cmd/server"
+	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/domain/service/handler"
+	servicemock "github.com/hortelanobruno/foaas-api/domain/service/mocks"
+	"github.com/hortelanobruno/foaas-api/domain/validator"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	"github.com/hortelanobruno/foaas-api/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIntegrationShouldCountTheRequestsThatPanicAsServerErrors(t *testing.T) {
+	// Initialization
+	messageService := &servicemock.MessageService{}
+	messageService.On("GetMessage", mock.Anything, "asshole", "123").
+		Run(func(mock.Arguments) { panic("unexpected nil response") }).
+		Return(nil, nil)
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), messageService)
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), nil,
+		server.WithMetrics(metrics.NewMetrics())))
+	defer shutdownServer(t, server)
+	httpClient := customhttp.NewClientImpl(5 * time.Second)
+	serverUrl := fmt.Sprintf("http://%s", server.Addr())
+
+	// Operation
+	messageResponse, messageErr := httpClient.Do(context.Background(),
+		customhttp.NewRequest(serverUrl+"/message").WithHeader(constants.UserIDHeader, "123"))
+	metricsResponse, metricsErr := httpClient.Do(context.Background(), customhttp.NewRequest(serverUrl+"/metrics"))
+
+	// Validation
+	require.NoError(t, messageErr)
+	assert.EqualValues(t, http.StatusInternalServerError, messageResponse.StatusCode)
+	require.NoError(t, metricsErr)
+	assert.Contains(t, string(metricsResponse.Body),
+		`foaas_api_http_requests_total{method="GET",route="/message",status="500"} 1`)
+}
@@ -1,9 +1,10 @@
 package integration
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"github.com/hortelanobruno/foaas-api/cmd/server"
+	"github.com/hortelanobruno/foaas-api/constants"
 	"github.com/hortelanobruno/foaas-api/domain/model"
 	"github.com/hortelanobruno/foaas-api/domain/service"
 	"github.com/hortelanobruno/foaas-api/domain/service/handler"
@@ -11,9 +12,12 @@ import (
 	customhttp "github.com/hortelanobruno/foaas-api/http"
 	"github.com/hortelanobruno/foaas-api/ratelimiter"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -29,18 +33,14 @@ func TestIntegrationShouldReturnNoErrorWhenRateLimitIsNotExceeded(t *testing.T)
 
 	rateLimiter := ratelimiter.NewLocalRateLimiter(2, time.Millisecond*time.Duration(10000))
 	httpClient := customhttp.NewClientImpl(time.Duration(5) * time.Second)
-	messageService := service.NewMessageServiceImpl(httpClient)
-	messageService.FoaasProtocol = "http"
-	messageService.FoaasDomain = strings.Split(foaasServer.URL, "//")[1]
+	foaasURL, _ := url.Parse(foaasServer.URL)
+	messageService := service.NewMessageServiceImpl(foaasURL, httpClient,
+		validator.NewResponseValidatorImpl(1000, 200))
 	messageValidator := validator.NewMessageValidatorImpl()
 	messageHandler := handler.NewMessageHandler(messageValidator, messageService)
-	serverPort := 4000
-	serverUrl := fmt.Sprintf("http://localhost:%d/message", serverPort)
-
-	go func() {
-		server := server.NewServer(messageHandler, rateLimiter)
-		server.Start(serverPort)
-	}()
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), rateLimiter))
+	defer shutdownServer(t, server)
+	serverUrl := fmt.Sprintf("http://%s/message", server.Addr())
 
 	// Operation
 	responseAttempt1, errorAttempt1 := requestMessageForUser(httpClient, serverUrl, userID)
@@ -62,18 +62,14 @@ func TestIntegrationShouldReturnErrorWhenRateLimitIsExceeded(t *testing.T) {
 
 	rateLimiter := ratelimiter.NewLocalRateLimiter(2, time.Millisecond*time.Duration(10000))
 	httpClient := customhttp.NewClientImpl(time.Duration(5) * time.Second)
-	messageService := service.NewMessageServiceImpl(httpClient)
-	messageService.FoaasProtocol = "http"
-	messageService.FoaasDomain = strings.Split(foaasServer.URL, "//")[1]
+	foaasURL, _ := url.Parse(foaasServer.URL)
+	messageService := service.NewMessageServiceImpl(foaasURL, httpClient,
+		validator.NewResponseValidatorImpl(1000, 200))
 	messageValidator := validator.NewMessageValidatorImpl()
 	messageHandler := handler.NewMessageHandler(messageValidator, messageService)
-	serverPort := 4001
-	serverUrl := fmt.Sprintf("http://localhost:%d/message", serverPort)
-
-	go func() {
-		server := server.NewServer(messageHandler, rateLimiter)
-		server.Start(serverPort)
-	}()
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), rateLimiter))
+	defer shutdownServer(t, server)
+	serverUrl := fmt.Sprintf("http://%s/message", server.Addr())
 
 	// Operation
 	responseAttempt1, errorAttempt1 := requestMessageForUser(httpClient, serverUrl, userID)
@@ -86,6 +82,110 @@ func TestIntegrationShouldReturnErrorWhenRateLimitIsExceeded(t *testing.T) {
 	assertNotValidResponse(t, responseAttempt3, errorAttempt3)
 }
 
+func TestIntegrationShouldDrainTheInFlightRequestsOnShutdown(t *testing.T) {
+	// Initialization
+	userID := "123"
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	foaasServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, `{"message": "Fuck you, asshole.","subtitle": "- %s"}`, userID)
+	}))
+	defer foaasServer.Close()
+
+	httpClient := customhttp.NewClientImpl(time.Duration(5) * time.Second)
+	foaasURL, _ := url.Parse(foaasServer.URL)
+	messageService := service.NewMessageServiceImpl(foaasURL, httpClient,
+		validator.NewResponseValidatorImpl(1000, 200))
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), messageService)
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), nil,
+		server.WithShutdownDelay(200*time.Millisecond)))
+	serverUrl := fmt.Sprintf("http://%s", server.Addr())
+
+	type result struct {
+		response *model.Response
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		response, err := requestMessageForUser(httpClient, serverUrl+"/message", userID)
+		results <- result{response: response, err: err}
+	}()
+	<-requested
+
+	// Operation
+	shutdownErrors := make(chan error, 1)
+	go func() {
+		shutdownErrors <- server.Shutdown(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	healthResponse, healthErr := httpClient.Do(context.Background(), customhttp.NewRequest(serverUrl+"/healthz"))
+	readyResponse, readyErr := httpClient.Do(context.Background(), customhttp.NewRequest(serverUrl+"/readyz"))
+	close(release)
+	messageResult := <-results
+	shutdownErr := <-shutdownErrors
+
+	// Validation
+	assert.Nil(t, healthErr)
+	assert.EqualValues(t, http.StatusOK, healthResponse.StatusCode)
+	assert.Nil(t, readyErr)
+	assert.EqualValues(t, http.StatusServiceUnavailable, readyResponse.StatusCode)
+	assertValidResponse(t, messageResult.response, messageResult.err)
+	assert.Nil(t, shutdownErr)
+	_, err := requestMessageForUser(httpClient, serverUrl+"/message", userID)
+	assert.NotNil(t, err)
+}
+
+func TestIntegrationShouldCloseTheConnectionsThatDontSendTheHeadersInTime(t *testing.T) {
+	// Initialization
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), nil)
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), nil,
+		server.WithTimeouts(100*time.Millisecond, time.Second, time.Second)))
+	defer shutdownServer(t, server)
+	connection, err := net.Dial("tcp", server.Addr())
+	require.NoError(t, err)
+	defer connection.Close()
+
+	// Operation
+	_, writeErr := fmt.Fprint(connection, "GET /healthz HTTP/1.1\r\nHost: localhost\r\n")
+	_ = connection.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, readErr := connection.Read(make([]byte, 1))
+
+	// Validation
+	assert.Nil(t, writeErr)
+	assert.ErrorIs(t, readErr, io.EOF)
+}
+
+func TestIntegrationShouldKeepTheServersThatShareTheHealthHandlerReadyWhenOneShutsDown(t *testing.T) {
+	// Initialization
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), nil)
+	healthHandler := handler.NewHealthHandler(nil)
+	stoppedServer := startServer(t, server.NewServer(messageHandler, healthHandler, nil))
+	runningServer := startServer(t, server.NewServer(messageHandler, healthHandler, nil))
+	defer shutdownServer(t, runningServer)
+
+	// Operation
+	shutdownServer(t, stoppedServer)
+	response, err := http.Get(fmt.Sprintf("http://%s/readyz", runningServer.Addr()))
+
+	// Validation
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+}
+
+func startServer(t *testing.T, server *server.Server) *server.Server {
+	err := server.Start("127.0.0.1:0")
+	assert.Nil(t, err)
+	return server
+}
+
+func shutdownServer(t *testing.T, server *server.Server) {
+	assert.Nil(t, server.Shutdown(context.Background()))
+}
+
 func assertNotValidResponse(t *testing.T, response *model.Response, err error) {
 	assert.NotNil(t, err)
 	assert.Nil(t, response)
@@ -99,12 +199,16 @@ func assertValidResponse(t *testing.T, response *model.Response, err error) {
 }
 
 func requestMessageForUser(httpClient *customhttp.ClientImpl, serverUrl, userId string) (*model.Response, error) {
-	body, err := httpClient.GetWithUserIdHeader(serverUrl, userId)
+	request := customhttp.NewRequest(serverUrl).WithHeader(constants.UserIDHeader, userId)
+	httpResponse, err := httpClient.Do(context.Background(), request)
 	if err != nil {
 		return nil, err
 	}
+	if !httpResponse.IsSuccess() {
+		return nil, fmt.Errorf("error executing request, status code: %v", httpResponse.StatusCode)
+	}
 	response := &model.Response{}
-	if err := json.Unmarshal(body, response); err != nil {
+	if err := httpResponse.Decode(response); err != nil {
 		return nil, err
 	}
 	return response, nil
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/cmd/server"
+	"github.com/hortelanobruno/foaas-api/domain/service"
+	"github.com/hortelanobruno/foaas-api/domain/service/handler"
+	"github.com/hortelanobruno/foaas-api/domain/validator"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	"github.com/hortelanobruno/foaas-api/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIntegrationShouldTraceTheRequestsUpToFoaas(t *testing.T) {
+	// Initialization
+	userID := "123"
+	var traceparent string
+	foaasServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, `{"message": "Fuck you, asshole.","subtitle": "- %s"}`, userID)
+	}))
+	defer foaasServer.Close()
+
+	exporter := testutil.RecordSpans()
+
+	foaasURL, _ := url.Parse(foaasServer.URL)
+	messageService := service.NewMessageServiceImpl(foaasURL, customhttp.NewClientImpl(5*time.Second),
+		validator.NewResponseValidatorImpl(1000, 200))
+	messageHandler := handler.NewMessageHandler(validator.NewMessageValidatorImpl(), messageService)
+	server := startServer(t, server.NewServer(messageHandler, handler.NewHealthHandler(nil), nil,
+		server.WithTracing(otel.GetTracerProvider())))
+	defer shutdownServer(t, server)
+	serverUrl := fmt.Sprintf("http://%s/message", server.Addr())
+	httpClient := customhttp.NewClientImpl(5 * time.Second)
+
+	// Operation
+	response, err := requestMessageForUser(httpClient, serverUrl, userID)
+
+	// Validation
+	assertValidResponse(t, response, err)
+	// The span of the server ends after the response is sent.
+	assert.Eventually(t, func() bool {
+		return len(exporter.GetSpans()) == 8
+	}, time.Second, 10*time.Millisecond)
+
+	spans := exporter.GetSpans()
+	callerSpan := findSpan(t, spans, "HTTP GET", trace.SpanKindClient, findSpan(t, spans, "ClientImpl.Do",
+		trace.SpanKindInternal, nil))
+	serverSpan := findSpan(t, spans, "/message", trace.SpanKindServer, callerSpan)
+	handlerSpan := findSpan(t, spans, "MessageHandler.renderMessage", trace.SpanKindInternal, serverSpan)
+	findSpan(t, spans, "Renderer.Render", trace.SpanKindInternal, handlerSpan)
+	serviceSpan := findSpan(t, spans, "MessageService.GetMessage", trace.SpanKindInternal, handlerSpan)
+	clientSpan := findSpan(t, spans, "ClientImpl.Do", trace.SpanKindInternal, serviceSpan)
+	attemptSpan := findSpan(t, spans, "HTTP GET", trace.SpanKindClient, clientSpan)
+	assert.EqualValues(t, fmt.Sprintf("00-%s-%s-01", attemptSpan.SpanContext.TraceID(),
+		attemptSpan.SpanContext.SpanID()), traceparent)
+}
+
+// findSpan returns the span with the name and the kind whose parent is the given one, or a root span when
+// the parent is nil.
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string, kind trace.SpanKind,
+	parent *tracetest.SpanStub) *tracetest.SpanStub {
+	for i, span := range spans {
+		if span.Name != name || span.SpanKind != kind {
+			continue
+		}
+		if (parent == nil && !span.Parent.IsValid()) ||
+			(parent != nil && span.Parent.SpanID() == parent.SpanContext.SpanID() &&
+				span.Parent.TraceID() == parent.SpanContext.TraceID()) {
+			return &spans[i]
+		}
+	}
+	t.Fatalf("span %s is missing", name)
+	return nil
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type loggerKey struct{}
+
+// formatters are the formats of the logs, by the value of --log-format.
+var formatters = map[string]func() logrus.Formatter{
+	"json": func() logrus.Formatter {
+		return &logrus.JSONFormatter{}
+	},
+	"text": func() logrus.Formatter {
+		return &logrus.TextFormatter{FullTimestamp: true}
+	},
+}
+
+// NewFormatter returns the formatter of the format, json or text.
+func NewFormatter(format string) (logrus.Formatter, error) {
+	formatter, exists := formatters[format]
+	if !exists {
+		return nil, fmt.Errorf("log format %q is not supported", format)
+	}
+	return formatter(), nil
+}
+
+// NewContext returns a copy of the context that carries the logger.
+func NewContext(ctx context.Context, logger *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the context, with the fields of the request it belongs to, or the
+// standard logger when there is none.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if logger, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		return logger
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
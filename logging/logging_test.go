@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewFormatter(t *testing.T) {
+	cases := []struct {
+		name              string
+		format            string
+		expectedFormatter logrus.Formatter
+		expectedError     error
+	}{
+		{
+			"Should return the JSON formatter",
+			"json",
+			&logrus.JSONFormatter{},
+			nil,
+		},
+		{
+			"Should return the text formatter",
+			"text",
+			&logrus.TextFormatter{FullTimestamp: true},
+			nil,
+		},
+		{
+			"Should return an error when the format is not supported",
+			"xml",
+			nil,
+			fmt.Errorf("log format \"xml\" is not supported"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			formatter, err := NewFormatter(c.format)
+
+			// Validation
+			assert.EqualValues(t, c.expectedFormatter, formatter)
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	cases := []struct {
+		name           string
+		ctx            context.Context
+		expectedFields logrus.Fields
+	}{
+		{
+			"Should return the standard logger when the context has no logger",
+			context.Background(),
+			logrus.Fields{},
+		},
+		{
+			"Should return the logger of the context",
+			NewContext(context.Background(), logrus.WithField("request_id", "abc")),
+			logrus.Fields{"request_id": "abc"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			logger := FromContext(c.ctx)
+
+			// Validation
+			assert.EqualValues(t, c.expectedFields, logger.Data)
+			assert.EqualValues(t, logrus.StandardLogger(), logger.Logger)
+		})
+	}
+}
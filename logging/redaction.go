@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	UserIDField = "user_id"
+	BodyField   = "body"
+
+	UserIDRedactionHash = "hash"
+	UserIDRedactionMask = "mask"
+
+	scrubbedValue = "<redacted>"
+	hashLength    = 16
+)
+
+// userIDRedactions redact the user IDs, by the value of --log-user-id-redaction.
+var userIDRedactions = map[string]func(r *Redactor, userID string) string{
+	// The hash is keyed, the user IDs are too short to hash them as they are, and stable while the key is.
+	UserIDRedactionHash: func(r *Redactor, userID string) string {
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(userID))
+		return "u_" + hex.EncodeToString(mac.Sum(nil))[:hashLength]
+	},
+	// The mask keeps a quarter of the user ID at most, and hides its length.
+	UserIDRedactionMask: func(_ *Redactor, userID string) string {
+		runes := []rune(userID)
+		return string(runes[:len(runes)/4]) + "***"
+	},
+}
+
+// Redactor hides the user IDs and the values of the scrubbed fields in the logs, so they can be shipped to
+// shared systems.
+type Redactor struct {
+	redactUserID func(r *Redactor, userID string) string
+	key          []byte
+	scrubFields  map[string]bool
+}
+
+// NewRedactor builds a redactor that hashes or masks the user IDs, and replaces the values of the scrubbed
+// fields. The hashes use a random key when the key is empty, so they only correlate the logs of a process.
+func NewRedactor(userIDRedaction, key string, scrubFields []string) (*Redactor, error) {
+	redactUserID, exists := userIDRedactions[userIDRedaction]
+	if !exists {
+		return nil, fmt.Errorf("user ID redaction %q is not supported", userIDRedaction)
+	}
+
+	redactor := &Redactor{
+		redactUserID: redactUserID,
+		key:          []byte(key),
+		scrubFields:  make(map[string]bool, len(scrubFields)),
+	}
+	if key == "" {
+		redactor.key = make([]byte, sha256.Size)
+		if _, err := rand.Read(redactor.key); err != nil {
+			return nil, fmt.Errorf("error generating the redaction key, err: %s", err.Error())
+		}
+	}
+	for _, field := range scrubFields {
+		redactor.scrubFields[field] = true
+	}
+	return redactor, nil
+}
+
+func (r *Redactor) RedactUserID(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	return r.redactUserID(r, userID)
+}
+
+// Formatter returns a formatter that redacts the entries before formatting them with the given one.
+func (r *Redactor) Formatter(formatter logrus.Formatter) logrus.Formatter {
+	return &redactingFormatter{
+		formatter: formatter,
+		redactor:  r,
+	}
+}
+
+type redactingFormatter struct {
+	formatter logrus.Formatter
+	redactor  *Redactor
+}
+
+// Format redacts the user ID field and, since the user ID ends up in the urls, the bodies and the errors
+// of foaas, every occurrence of it in the message and the other fields.
+func (f *redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := &logrus.Entry{
+		Logger:  entry.Logger,
+		Data:    make(logrus.Fields, len(entry.Data)),
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Caller:  entry.Caller,
+		Buffer:  entry.Buffer,
+		Context: entry.Context,
+	}
+
+	userID, _ := entry.Data[UserIDField].(string)
+	redactedUserID := f.redactor.RedactUserID(userID)
+	redact := func(value string) string {
+		if userID == "" {
+			return value
+		}
+		value = replaceWord(value, userID, redactedUserID)
+		if escaped := url.PathEscape(userID); escaped != userID {
+			value = replaceWord(value, escaped, redactedUserID)
+		}
+		return value
+	}
+
+	for field, value := range entry.Data {
+		switch {
+		case field == UserIDField:
+			redacted.Data[field] = redactedUserID
+		case f.redactor.scrubFields[field]:
+			redacted.Data[field] = scrubbedValue
+		default:
+			switch typed := value.(type) {
+			case string:
+				value = redact(typed)
+			case error:
+				value = redact(typed.Error())
+			}
+			redacted.Data[field] = value
+		}
+	}
+	redacted.Message = redact(entry.Message)
+	return f.formatter.Format(redacted)
+}
+
+// replaceWord replaces the occurrences of the word that are not part of a longer word, so that short user IDs,
+// like 200, don't redact the status codes of the messages.
+func replaceWord(s, word, replacement string) string {
+	var builder strings.Builder
+	for {
+		index := strings.Index(s, word)
+		if index < 0 {
+			builder.WriteString(s)
+			return builder.String()
+		}
+		end := index + len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:index])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		builder.WriteString(s[:index])
+		if isWordRune(before) || isWordRune(after) {
+			builder.WriteString(word)
+		} else {
+			builder.WriteString(replacement)
+		}
+		s = s[end:]
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
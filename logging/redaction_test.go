@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewRedactor(t *testing.T) {
+	cases := []struct {
+		name            string
+		userIDRedaction string
+		expectedError   error
+	}{
+		{
+			"Should return a redactor that hashes the user IDs",
+			UserIDRedactionHash,
+			nil,
+		},
+		{
+			"Should return a redactor that masks the user IDs",
+			UserIDRedactionMask,
+			nil,
+		},
+		{
+			"Should return an error when the redaction is not supported",
+			"drop",
+			fmt.Errorf("user ID redaction \"drop\" is not supported"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			redactor, err := NewRedactor(c.userIDRedaction, "", nil)
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+			assert.EqualValues(t, c.expectedError == nil, redactor != nil)
+		})
+	}
+}
+
+func TestRedactUserID(t *testing.T) {
+	cases := []struct {
+		name            string
+		userIDRedaction string
+		key             string
+		userID          string
+		expectedUserID  string
+	}{
+		{
+			"Should hash the user ID with the key",
+			UserIDRedactionHash,
+			"secret",
+			"123",
+			"u_77de38e4b50e618a",
+		},
+		{
+			"Should hash the user ID differently with another key",
+			UserIDRedactionHash,
+			"another secret",
+			"123",
+			"u_215b3357c8c42307",
+		},
+		{
+			"Should mask the whole user ID when it's short",
+			UserIDRedactionMask,
+			"",
+			"123",
+			"***",
+		},
+		{
+			"Should mask all but the first quarter of the user ID",
+			UserIDRedactionMask,
+			"",
+			"johndoe@example.com",
+			"john***",
+		},
+		{
+			"Should not redact an empty user ID",
+			UserIDRedactionHash,
+			"secret",
+			"",
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			redactor, _ := NewRedactor(c.userIDRedaction, c.key, nil)
+
+			// Operation
+			userID := redactor.RedactUserID(c.userID)
+
+			// Validation
+			assert.EqualValues(t, c.expectedUserID, userID)
+		})
+	}
+}
+
+func TestRedactingFormatter(t *testing.T) {
+	cases := []struct {
+		name          string
+		fields        logrus.Fields
+		message       string
+		expectedEntry string
+	}{
+		{
+			"Should redact the user ID in the field and in the message",
+			logrus.Fields{UserIDField: "123", "route": "/message"},
+			"Error executing GET request for url: https://foaas.com/asshole/123, status code: 1234",
+			`{"level":"error","msg":"Error executing GET request for url: https://foaas.com/asshole/***, ` +
+				`status code: 1234","route":"/message","user_id":"***"}`,
+		},
+		{
+			"Should redact the escaped user ID in the urls",
+			logrus.Fields{UserIDField: "john doe"},
+			"Starting to GET response for https://foaas.com/asshole/john%20doe",
+			`{"level":"error","msg":"Starting to GET response for https://foaas.com/asshole/jo***",` +
+				`"user_id":"jo***"}`,
+		},
+		{
+			"Should redact the user ID in the other fields and the errors",
+			logrus.Fields{UserIDField: "123", "key": "asshole/123", logrus.ErrorKey: fmt.Errorf("user 123")},
+			"Serving stale message",
+			`{"error":"user ***","key":"asshole/***","level":"error","msg":"Serving stale message","user_id":"***"}`,
+		},
+		{
+			"Should scrub the fields",
+			logrus.Fields{BodyField: `{"subtitle": "- John"}`},
+			"Finishing to get response",
+			`{"body":"<redacted>","level":"error","msg":"Finishing to get response"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			output := &bytes.Buffer{}
+			logger := logrus.New()
+			logger.SetOutput(output)
+			redactor, _ := NewRedactor(UserIDRedactionMask, "", []string{BodyField})
+			logger.SetFormatter(redactor.Formatter(&logrus.JSONFormatter{DisableTimestamp: true}))
+			entry := logger.WithFields(c.fields)
+
+			// Operation
+			entry.Error(c.message)
+
+			// Validation
+			assert.JSONEq(t, c.expectedEntry, output.String())
+			assert.NotEqualValues(t, "***", entry.Data[UserIDField])
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/hortelanobruno/foaas-api/domain/service"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	"github.com/hortelanobruno/foaas-api/ratelimiter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	namespace      = "foaas_api"
+	unmatchedRoute = "unmatched"
+	errorStatus    = "error"
+)
+
+// Metrics gathers the metrics of the server in its own registry, to expose them in the Prometheus format.
+type Metrics struct {
+	registry           *prometheus.Registry
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	rateLimitDecisions *prometheus.CounterVec
+	upstreamDuration   *prometheus.HistogramVec
+	upstreamErrors     *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests served, by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of the HTTP requests served, by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		rateLimitDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_decisions_total",
+			Help:      "Number of requests allowed or denied by the rate limiter.",
+		}, []string{"decision"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of the attempts to call foaas, by status code, which is error when there's none.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_errors_total",
+			Help:      "Number of failed attempts to call foaas, by error code.",
+		}, []string{"code"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.rateLimitDecisions,
+		m.upstreamDuration,
+		m.upstreamErrors,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a request served for the route, which is the template of the path, like
+// /message/:operation, to keep the number of series bounded.
+func (m *Metrics) ObserveRequest(route, method string, statusCode int, duration time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	status := strconv.Itoa(statusCode)
+	m.httpRequests.WithLabelValues(route, method, status).Inc()
+	m.httpDuration.WithLabelValues(route, method, status).Observe(duration.Seconds())
+}
+
+// ObserveAttempt records an attempt to call foaas, see http.WithAttemptObserver.
+func (m *Metrics) ObserveAttempt(duration time.Duration, response *customhttp.Response, err error) {
+	status := errorStatus
+	if response != nil {
+		status = strconv.Itoa(response.StatusCode)
+		if !response.IsSuccess() {
+			err = apierror.NewUpstreamStatus(response.StatusCode, nil)
+		}
+	}
+	m.upstreamDuration.WithLabelValues(status).Observe(duration.Seconds())
+	if err != nil && apierror.KindOf(err) != apierror.KindCanceled {
+		m.upstreamErrors.WithLabelValues(apierror.KindOf(err).Code()).Inc()
+	}
+}
+
+// ObservedRateLimiter counts the requests allowed and denied by the rate limiter.
+func (m *Metrics) ObservedRateLimiter(rateLimiter ratelimiter.RateLimiter) ratelimiter.RateLimiter {
+	return &observedRateLimiter{
+		rateLimiter: rateLimiter,
+		metrics:     m,
+	}
+}
+
+// WatchRateLimiter exposes the number of users tracked by the rate limiter.
+func (m *Metrics) WatchRateLimiter(trackedUsers func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rate_limiter_tracked_users",
+		Help:      "Number of users tracked by the rate limiter.",
+	}, func() float64 {
+		return float64(trackedUsers())
+	}))
+}
+
+// WatchCache exposes the stats of the cache of the messages.
+func (m *Metrics) WatchCache(stats func() service.CacheStats) {
+	counters := []struct {
+		name  string
+		help  string
+		value func(service.CacheStats) uint64
+	}{
+		{"cache_hits_total", "Number of messages served fresh from the cache.",
+			func(s service.CacheStats) uint64 { return s.Hits }},
+		{"cache_misses_total", "Number of messages not found fresh in the cache.",
+			func(s service.CacheStats) uint64 { return s.Misses }},
+		{"cache_evictions_total", "Number of messages evicted from the cache.",
+			func(s service.CacheStats) uint64 { return s.Evictions }},
+		{"cache_stale_hits_total", "Number of expired messages served from the cache because foaas failed.",
+			func(s service.CacheStats) uint64 { return s.StaleHits }},
+	}
+	for _, counter := range counters {
+		value := counter.value
+		m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      counter.name,
+			Help:      counter.help,
+		}, func() float64 {
+			return float64(value(stats()))
+		}))
+	}
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cache_size",
+		Help:      "Number of messages in the cache.",
+	}, func() float64 {
+		return float64(stats().Size)
+	}))
+}
+
+// WatchConnectionPool exposes the stats of the connections to foaas.
+func (m *Metrics) WatchConnectionPool(stats func() customhttp.PoolStats) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "upstream_open_connections",
+			Help:      "Number of open connections to foaas.",
+		}, func() float64 {
+			return float64(stats().OpenConnections)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_opened_connections_total",
+			Help:      "Number of connections opened to foaas.",
+		}, func() float64 {
+			return float64(stats().OpenedConnections)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_reused_connections_total",
+			Help:      "Number of requests to foaas that reused an idle connection.",
+		}, func() float64 {
+			return float64(stats().ReusedConnections)
+		}),
+	)
+}
+
+type observedRateLimiter struct {
+	rateLimiter ratelimiter.RateLimiter
+	metrics     *Metrics
+}
+
+func (o *observedRateLimiter) AllowRequest(userID string) bool {
+	allowed := o.rateLimiter.AllowRequest(userID)
+	decision := "allowed"
+	if !allowed {
+		decision = "denied"
+	}
+	o.metrics.rateLimitDecisions.WithLabelValues(decision).Inc()
+	return allowed
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"github.com/hortelanobruno/foaas-api/cache"
+	"github.com/hortelanobruno/foaas-api/domain/service"
+	customhttp "github.com/hortelanobruno/foaas-api/http"
+	"github.com/hortelanobruno/foaas-api/ratelimiter/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveRequest(t *testing.T) {
+	// Initialization
+	metrics := NewMetrics()
+
+	// Operation
+	metrics.ObserveRequest("/message/:operation", http.MethodGet, http.StatusOK, time.Millisecond)
+	metrics.ObserveRequest("/message/:operation", http.MethodGet, http.StatusOK, time.Millisecond)
+	metrics.ObserveRequest("", http.MethodGet, http.StatusNotFound, time.Millisecond)
+
+	// Validation
+	assert.EqualValues(t, 2, testutil.ToFloat64(
+		metrics.httpRequests.WithLabelValues("/message/:operation", http.MethodGet, "200")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(
+		metrics.httpRequests.WithLabelValues(unmatchedRoute, http.MethodGet, "404")))
+	assert.EqualValues(t, 2, testutil.CollectAndCount(metrics.httpDuration))
+}
+
+func TestObserveAttempt(t *testing.T) {
+	cases := []struct {
+		name           string
+		response       *customhttp.Response
+		err            error
+		expectedStatus string
+		expectedCode   string
+	}{
+		{
+			"Should count a successful attempt without errors",
+			&customhttp.Response{StatusCode: http.StatusOK},
+			nil,
+			"200",
+			"",
+		},
+		{
+			"Should count an attempt with a server error status code as an error",
+			&customhttp.Response{StatusCode: http.StatusServiceUnavailable},
+			nil,
+			"503",
+			"upstream_server_error",
+		},
+		{
+			"Should count an attempt with a client error status code as an error",
+			&customhttp.Response{StatusCode: http.StatusNotFound},
+			nil,
+			"404",
+			"upstream_client_error",
+		},
+		{
+			"Should count an attempt without response by the code of its error",
+			nil,
+			fmt.Errorf("error doing the request, err: %w", errTimeout{}),
+			errorStatus,
+			"upstream_timeout",
+		},
+		{
+			"Should not count a canceled attempt as an error",
+			nil,
+			fmt.Errorf("error doing the request, err: %w", context.Canceled),
+			errorStatus,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			metrics := NewMetrics()
+
+			// Operation
+			metrics.ObserveAttempt(time.Millisecond, c.response, c.err)
+
+			// Validation
+			assert.EqualValues(t, 1, testutil.CollectAndCount(metrics.upstreamDuration))
+			assert.Contains(t, gather(t, metrics), fmt.Sprintf(`upstream_request_duration_seconds_count{status=%q} 1`,
+				c.expectedStatus))
+			if c.expectedCode == "" {
+				assert.EqualValues(t, 0, testutil.CollectAndCount(metrics.upstreamErrors))
+				return
+			}
+			assert.EqualValues(t, 1, testutil.ToFloat64(metrics.upstreamErrors.WithLabelValues(c.expectedCode)))
+		})
+	}
+}
+
+func TestObservedRateLimiter(t *testing.T) {
+	// Initialization
+	metrics := NewMetrics()
+	rateLimiterMock := &mocks.RateLimiter{}
+	rateLimiterMock.On("AllowRequest", "123").Return(true).Twice()
+	rateLimiterMock.On("AllowRequest", "123").Return(false).Once()
+	rateLimiter := metrics.ObservedRateLimiter(rateLimiterMock)
+
+	// Operation
+	allowed := []bool{rateLimiter.AllowRequest("123"), rateLimiter.AllowRequest("123"),
+		rateLimiter.AllowRequest("123")}
+
+	// Validation
+	assert.EqualValues(t, []bool{true, true, false}, allowed)
+	assert.EqualValues(t, 2, testutil.ToFloat64(metrics.rateLimitDecisions.WithLabelValues("allowed")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(metrics.rateLimitDecisions.WithLabelValues("denied")))
+}
+
+func TestWatchStats(t *testing.T) {
+	// Initialization
+	metrics := NewMetrics()
+
+	// Operation
+	metrics.WatchRateLimiter(func() int {
+		return 3
+	})
+	metrics.WatchCache(func() service.CacheStats {
+		return service.CacheStats{Stats: cache.Stats{Hits: 4, Misses: 5, Evictions: 6, Size: 7}, StaleHits: 8}
+	})
+	metrics.WatchConnectionPool(func() customhttp.PoolStats {
+		return customhttp.PoolStats{OpenConnections: 9, OpenedConnections: 10, ReusedConnections: 11}
+	})
+
+	// Validation
+	exposition := gather(t, metrics)
+	for _, line := range []string{
+		"foaas_api_rate_limiter_tracked_users 3",
+		"foaas_api_cache_hits_total 4",
+		"foaas_api_cache_misses_total 5",
+		"foaas_api_cache_evictions_total 6",
+		"foaas_api_cache_size 7",
+		"foaas_api_cache_stale_hits_total 8",
+		"foaas_api_upstream_open_connections 9",
+		"foaas_api_upstream_opened_connections_total 10",
+		"foaas_api_upstream_reused_connections_total 11",
+	} {
+		assert.Contains(t, exposition, line+"\n")
+	}
+}
+
+// gather returns the metrics as they are served.
+func gather(t *testing.T, metrics *Metrics) string {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain"))
+	return recorder.Body.String()
+}
+
+type errTimeout struct{}
+
+func (errTimeout) Error() string   { return "timeout" }
+func (errTimeout) Timeout() bool   { return true }
+func (errTimeout) Temporary() bool { return true }
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// Logger puts a logger with the fields of the request in its context, see logging.FromContext, and logs
+// the request once it's served, with the trace ID when it's traced. It replaces the logger of gin, it must go
+// after RequestID and the tracing.
+func Logger() gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		fields := logrus.Fields{
+			"request_id":        c.GetString(requestIDKey),
+			logging.UserIDField: c.GetHeader(constants.UserIDHeader),
+			"route":             c.FullPath(),
+			"method":            c.Request.Method,
+		}
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+			fields["trace_id"] = spanContext.TraceID().String()
+		}
+		logger := logging.FromContext(c.Request.Context()).WithFields(fields)
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+
+		c.Next()
+
+		logger.WithFields(logrus.Fields{
+			"status":                  c.Writer.Status(),
+			"latency_in_milliseconds": float64(time.Since(start)) / float64(time.Millisecond),
+		}).Infof("Serving %s %s", c.Request.Method, c.Request.URL.Path)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoggerShouldLogWithTheFieldsOfTheRequest(t *testing.T) {
+	// Initialization
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	engine := gin.New()
+	engine.Use(RequestID(), Logger())
+	engine.GET("/message/:operation", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Errorf("Error getting the message")
+		c.Status(http.StatusBadGateway)
+	})
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/message/asshole.svg", nil)
+	request.Header.Set(constants.RequestIDHeader, "abc")
+	request.Header.Set(constants.UserIDHeader, "123")
+
+	// Operation
+	engine.ServeHTTP(w, request)
+
+	// Validation
+	entries := hook.AllEntries()
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.EqualValues(t, "abc", entry.Data["request_id"])
+		assert.EqualValues(t, "123", entry.Data["user_id"])
+		assert.EqualValues(t, "/message/:operation", entry.Data["route"])
+		assert.EqualValues(t, "GET", entry.Data["method"])
+	}
+	assert.EqualValues(t, logrus.ErrorLevel, entries[0].Level)
+	assert.EqualValues(t, "Error getting the message", entries[0].Message)
+	assert.EqualValues(t, logrus.InfoLevel, entries[1].Level)
+	assert.EqualValues(t, "Serving GET /message/asshole.svg", entries[1].Message)
+	assert.EqualValues(t, http.StatusBadGateway, entries[1].Data["status"])
+	assert.Contains(t, entries[1].Data, "latency_in_milliseconds")
+}
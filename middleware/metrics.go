@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/metrics"
+	"time"
+)
+
+// Metrics observes the requests by their route, not their path, which has the user input.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		m.ObserveRequest(c.FullPath(), c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
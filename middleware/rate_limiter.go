@@ -1,24 +1,32 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/apierror"
+	"github.com/hortelanobruno/foaas-api/audit"
 	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/logging"
 	"github.com/hortelanobruno/foaas-api/ratelimiter"
-	"github.com/sirupsen/logrus"
-	"net/http"
 )
 
-func RateLimiter(rateLimiter ratelimiter.RateLimiter) gin.HandlerFunc {
+var errTooManyRequests = errors.New("too many requests, try again later")
+
+// RateLimiter rejects the requests of the users that exceed the rate limit, recording them in the audit.
+func RateLimiter(rateLimiter ratelimiter.RateLimiter, auditor *audit.Auditor) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		userID := c.GetHeader(constants.UserIDHeader)
 
 		if !rateLimiter.AllowRequest(userID) {
-			logrus.Errorf("Too Many Requests for userID: %s", userID)
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": http.StatusText(http.StatusTooManyRequests),
+			logging.FromContext(c.Request.Context()).Errorf("Too Many Requests")
+			auditor.Record(audit.Event{
+				Type:      audit.EventRateLimitDenied,
+				RequestID: c.GetString(requestIDKey),
+				UserID:    userID,
+				Route:     c.FullPath(),
 			})
-			c.Abort()
+			apierror.WriteProblem(c, apierror.New(apierror.KindRateLimited, errTooManyRequests))
 			return
 		}
 
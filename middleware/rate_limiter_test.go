@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/audit"
+	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/hortelanobruno/foaas-api/ratelimiter/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	cases := []struct {
+		name               string
+		allowRequest       bool
+		expectedStatusCode int
+		expectedAudit      string
+	}{
+		{
+			"Should serve the request when the rate limiter allows it",
+			true,
+			200,
+			``,
+		},
+		{
+			"Should reject the request and record it in the audit when the rate limiter denies it",
+			false,
+			429,
+			`"type":"rate_limit_denied","request_id":"abc","user_id":"123","route":"/message/:id"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			rateLimiter := &mocks.RateLimiter{}
+			rateLimiter.On("AllowRequest", "123").Return(c.allowRequest)
+			output := &bytes.Buffer{}
+			engine := gin.New()
+			engine.Use(RequestID(), RateLimiter(rateLimiter, audit.NewAuditor(output)))
+			engine.GET("/message/:id", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/message/1", nil)
+			request.Header.Set(constants.RequestIDHeader, "abc")
+			request.Header.Set(constants.UserIDHeader, "123")
+
+			// Operation
+			engine.ServeHTTP(w, request)
+
+			// Validation
+			assert.EqualValues(t, c.expectedStatusCode, w.Code)
+			if c.expectedAudit == "" {
+				assert.Empty(t, output.String())
+			} else {
+				assert.Contains(t, output.String(), c.expectedAudit)
+			}
+			rateLimiter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateLimiterShouldNotFailWhenTheAuditIsDisabled(t *testing.T) {
+	// Initialization
+	rateLimiter := &mocks.RateLimiter{}
+	rateLimiter.On("AllowRequest", "123").Return(false)
+	engine := gin.New()
+	engine.Use(RateLimiter(rateLimiter, nil))
+	engine.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.Header.Set(constants.UserIDHeader, "123")
+
+	// Operation
+	engine.ServeHTTP(w, request)
+
+	// Validation
+	assert.EqualValues(t, 429, w.Code)
+}
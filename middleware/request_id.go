@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/constants"
+	"regexp"
+)
+
+const requestIDKey = "requestID"
+
+// validRequestID limits the request IDs of the clients, which end up in the logs, to the usual formats, like
+// UUIDs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes the request ID of the X-Request-ID header, or generates one when it's missing or not
+// valid, and echoes it back in the response.
+func RequestID() gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(constants.RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(constants.RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	// The reader of crypto/rand doesn't fail on the supported platforms.
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
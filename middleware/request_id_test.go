@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hortelanobruno/foaas-api/constants"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	generatedRequestID := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	cases := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			"Should echo the request ID of the client",
+			"0f8fad5b-d9cb-469f-a165-70867728950e",
+			"0f8fad5b-d9cb-469f-a165-70867728950e",
+		},
+		{
+			"Should generate a request ID when the client doesn't send one",
+			"",
+			"",
+		},
+		{
+			"Should generate a request ID when the one of the client has invalid characters",
+			"abc\ninjected",
+			"",
+		},
+		{
+			"Should generate a request ID when the one of the client is too long",
+			strings.Repeat("a", 129),
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			var contextRequestID string
+			engine := gin.New()
+			engine.Use(RequestID())
+			engine.GET("/", func(c *gin.Context) {
+				contextRequestID = c.GetString(requestIDKey)
+			})
+
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequest("GET", "/", nil)
+			request.Header.Set(constants.RequestIDHeader, c.requestID)
+
+			// Operation
+			engine.ServeHTTP(w, request)
+
+			// Validation
+			requestID := w.Header().Get(constants.RequestIDHeader)
+			if c.expectedRequestID != "" {
+				assert.EqualValues(t, c.expectedRequestID, requestID)
+			} else {
+				assert.Regexp(t, generatedRequestID, requestID)
+			}
+			assert.EqualValues(t, requestID, contextRequestID)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package ratelimiter
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -11,21 +12,46 @@ type LocalRateLimiter struct {
 	requestsByUser           map[string][]time.Time
 	mutex                    *sync.Mutex
 	now                      func() time.Time
+	quotaExhaustedListener   func(userID string)
 }
 
-func NewLocalRateLimiter(rateLimitCount int, rateWindowInMilliseconds time.Duration) *LocalRateLimiter {
-	return &LocalRateLimiter{
+type LocalRateLimiterOption func(*LocalRateLimiter)
+
+// WithQuotaExhaustedListener calls the listener when a user makes the last request allowed in the window, out
+// of the lock of the rate limiter.
+func WithQuotaExhaustedListener(listener func(userID string)) LocalRateLimiterOption {
+	return func(s *LocalRateLimiter) {
+		s.quotaExhaustedListener = listener
+	}
+}
+
+func NewLocalRateLimiter(rateLimitCount int, rateWindowInMilliseconds time.Duration,
+	options ...LocalRateLimiterOption) *LocalRateLimiter {
+	rateLimiter := &LocalRateLimiter{
 		rateLimitCount:           rateLimitCount,
 		rateWindowInMilliseconds: rateWindowInMilliseconds,
 		requestsByUser:           make(map[string][]time.Time, 0),
 		mutex:                    &sync.Mutex{},
 		now:                      time.Now,
 	}
+	for _, option := range options {
+		option(rateLimiter)
+	}
+	return rateLimiter
 }
 
 // AllowRequest returns true if in the last past X milliseconds, there were fewer requests than the rate limit.
 // Remove all the old requests from the map.
 func (s *LocalRateLimiter) AllowRequest(userID string) bool {
+	allowed, remaining := s.allowRequest(userID)
+	if allowed && remaining == 0 && s.quotaExhaustedListener != nil {
+		s.quotaExhaustedListener(userID)
+	}
+	return allowed
+}
+
+// allowRequest returns whether the request is allowed and how many requests the user has left in the window.
+func (s *LocalRateLimiter) allowRequest(userID string) (bool, int) {
 	now := s.now()
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -33,17 +59,17 @@ func (s *LocalRateLimiter) AllowRequest(userID string) bool {
 	requests, exists := s.requestsByUser[userID]
 	if !exists {
 		s.requestsByUser[userID] = []time.Time{now}
-		return true
+		return true, s.rateLimitCount - 1
 	}
 
 	newRequests := s.getRequestsInTheWindowTime(requests, now)
 	s.requestsByUser[userID] = newRequests
 	if len(newRequests) >= s.rateLimitCount {
-		return false
+		return false, 0
 	}
 
 	s.requestsByUser[userID] = append(newRequests, now)
-	return true
+	return true, s.rateLimitCount - len(newRequests) - 1
 }
 
 func (s *LocalRateLimiter) getRequestsInTheWindowTime(requests []time.Time, now time.Time) []time.Time {
@@ -55,3 +81,17 @@ func (s *LocalRateLimiter) getRequestsInTheWindowTime(requests []time.Time, now
 	}
 	return newRequests
 }
+
+// TrackedUsers returns the number of users whose requests are kept in memory.
+func (s *LocalRateLimiter) TrackedUsers() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.requestsByUser)
+}
+
+// CheckHealth reports whether the backend of the requests is available, which is always the case of the
+// memory of the process.
+func (s *LocalRateLimiter) CheckHealth(_ context.Context) error {
+	return nil
+}
@@ -202,3 +202,70 @@ func TestAllowRequestShouldReturnFalseWhenRequestsInWindowTimeIsGreaterThanLimit
 			time.Date(2022, time.March, 30, 0, 0, 17, 00, time.UTC),
 		})
 }
+
+func TestTrackedUsersShouldReturnTheNumberOfUsers(t *testing.T) {
+	// Initialization
+	rateLimiter := NewLocalRateLimiter(5, time.Duration(10000)*time.Millisecond)
+	rateLimiter.AllowRequest("123")
+	rateLimiter.AllowRequest("123")
+	rateLimiter.AllowRequest("456")
+
+	// Operation
+	trackedUsers := rateLimiter.TrackedUsers()
+
+	// Validation
+	assert.EqualValues(t, 2, trackedUsers)
+}
+
+func TestAllowRequestShouldNotifyTheListenerWhenTheQuotaIsExhausted(t *testing.T) {
+	cases := []struct {
+		name              string
+		rateLimitCount    int
+		requests          int
+		expectedExhausted []string
+	}{
+		{
+			"Should not notify the listener while there are requests left",
+			3,
+			2,
+			nil,
+		},
+		{
+			"Should notify the listener on the last request allowed",
+			3,
+			3,
+			[]string{"123"},
+		},
+		{
+			"Should not notify the listener again on the requests denied",
+			3,
+			5,
+			[]string{"123"},
+		},
+		{
+			"Should notify the listener on the first request when only one is allowed",
+			1,
+			1,
+			[]string{"123"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Initialization
+			var exhausted []string
+			rateLimiter := NewLocalRateLimiter(c.rateLimitCount, time.Duration(10000)*time.Millisecond,
+				WithQuotaExhaustedListener(func(userID string) {
+					exhausted = append(exhausted, userID)
+				}))
+
+			// Operation
+			for i := 0; i < c.requests; i++ {
+				rateLimiter.AllowRequest("123")
+			}
+
+			// Validation
+			assert.EqualValues(t, c.expectedExhausted, exhausted)
+		})
+	}
+}
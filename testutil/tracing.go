@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"sync"
+)
+
+// spanExporter records the spans of the tests. The global tracers delegate to the first provider that is
+// registered, so it's registered once.
+var (
+	spanExporter   = tracetest.NewInMemoryExporter()
+	registerTracer sync.Once
+)
+
+// RecordSpans registers the global tracer provider and propagator, the first time, and returns the
+// exporter of the spans with the ones of the previous tests removed.
+func RecordSpans() *tracetest.InMemoryExporter {
+	registerTracer.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	spanExporter.Reset()
+	return spanExporter
+}
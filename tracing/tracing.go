@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"os"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	serviceName = "foaas-api"
+)
+
+type Config struct {
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	SampleRatio  float64
+}
+
+// exporters build the exporter of the spans, by the value of --tracing-exporter.
+var exporters = map[string]func(ctx context.Context, config Config) (sdktrace.SpanExporter, error){
+	ExporterNone: func(_ context.Context, _ Config) (sdktrace.SpanExporter, error) {
+		return nil, nil
+	},
+	ExporterStdout: func(_ context.Context, _ Config) (sdktrace.SpanExporter, error) {
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	},
+	ExporterOTLP: func(ctx context.Context, config Config) (sdktrace.SpanExporter, error) {
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.OTLPEndpoint)}
+		if config.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	},
+}
+
+func (c Config) Validate() error {
+	if _, exists := exporters[c.Exporter]; !exists {
+		return fmt.Errorf("tracing exporter %q is not supported", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// Setup registers the global tracer provider, which samples the traces by the ratio unless the caller sampled
+// them already, and the W3C trace context propagator. It returns a nil provider when the exporter is none, so
+// the spans are not recorded at all. See Shutdown to flush the spans.
+func Setup(ctx context.Context, config Config) (*sdktrace.TracerProvider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	exporter, err := exporters[config.Exporter](ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("error building the tracing exporter, err: %s", err.Error())
+	}
+	if exporter == nil {
+		return nil, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider, nil
+}
+
+// Shutdown flushes the spans of the provider registered by Setup, if any.
+func Shutdown(ctx context.Context) error {
+	if provider, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		return provider.Shutdown(ctx)
+	}
+	return nil
+}
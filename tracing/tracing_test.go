@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name          string
+		config        Config
+		expectedError error
+	}{
+		{
+			"Should return a nil error when the exporter is none",
+			Config{Exporter: ExporterNone, SampleRatio: 1},
+			nil,
+		},
+		{
+			"Should return a nil error when the exporter is otlp",
+			Config{Exporter: ExporterOTLP, OTLPEndpoint: "localhost:4318", SampleRatio: 0.5},
+			nil,
+		},
+		{
+			"Should return an error when the exporter is not supported",
+			Config{Exporter: "jaeger", SampleRatio: 1},
+			fmt.Errorf("tracing exporter \"jaeger\" is not supported"),
+		},
+		{
+			"Should return an error when the sample ratio is greater than 1",
+			Config{Exporter: ExporterStdout, SampleRatio: 1.5},
+			fmt.Errorf("tracing sample ratio must be between 0 and 1"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Operation
+			err := c.config.Validate()
+
+			// Validation
+			assert.EqualValues(t, c.expectedError, err)
+		})
+	}
+}
+
+func TestSetupShouldNotBuildAProviderWhenTheExporterIsNone(t *testing.T) {
+	// Operation
+	provider, err := Setup(context.Background(), Config{Exporter: ExporterNone, SampleRatio: 1})
+
+	// Validation
+	assert.Nil(t, err)
+	assert.Nil(t, provider)
+}